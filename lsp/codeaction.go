@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/raiguard/luapls/util"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) textDocumentCodeAction(ctx *glsp.Context, params *protocol.CodeActionParams) (any, error) {
+	file := s.getFile(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+	return getCodeActions(file, params.Context.Diagnostics), nil
+}
+
+// getCodeActions returns the quick fixes of the given diagnostics.
+func getCodeActions(file *File, diagnostics []protocol.Diagnostic) []protocol.CodeAction {
+	actions := []protocol.CodeAction{}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == nil {
+			continue
+		}
+		switch diagnostic.Code.Value {
+		case "unused-function", "unused-label", "unused-local", "unused-parameter":
+			actions = append(actions, getUnusedFixes(file, diagnostic)...)
+		}
+	}
+	return actions
+}
+
+// getUnusedFixes returns the fixes of an unused variable or label: prefixing
+// it with an underscore, or removing its declaration.
+func getUnusedFixes(file *File, diagnostic protocol.Diagnostic) []protocol.CodeAction {
+	pos := file.File.ToPos(diagnostic.Range.Start)
+	nodePath := ast.GetNode(&file.File.Block, pos)
+	var stmt ast.Statement
+	ident, ok := nodePath.Node.(*ast.Identifier)
+	if label, isLabel := nodePath.Node.(*ast.LabelStatement); isLabel {
+		// Labels are leaves, so their name is not found
+		stmt, ident, ok = label, label.Name, label.Name != nil
+	}
+	if !ok {
+		return nil
+	}
+	name := ident.Token.Literal
+	fix := func(title string, edits []protocol.TextEdit) protocol.CodeAction {
+		return protocol.CodeAction{
+			Title:       title,
+			Kind:        util.Ptr(protocol.CodeActionKindQuickFix),
+			Diagnostics: []protocol.Diagnostic{diagnostic},
+			Edit:        &protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{file.Path: edits}},
+		}
+	}
+
+	actions := []protocol.CodeAction{}
+	binding := file.Env.Scopes.Uses[ident]
+	if binding != nil {
+		if edits, err := getRenameEdits(file, pos, "_"+name); err == nil {
+			actions = append(actions, fix(fmt.Sprintf("Prefix '%s' with '_'", name), edits))
+		}
+	}
+
+	for i := len(nodePath.Parents) - 1; i >= 0 && stmt == nil; i-- {
+		stmt, _ = nodePath.Parents[i].(ast.Statement)
+	}
+	switch stmt := stmt.(type) {
+	case *ast.LabelStatement:
+	case *ast.LocalStatement:
+		// The other variables would lose their values
+		if binding == nil || binding.Kind != types.LocalBinding || len(stmt.Names.Pairs) > 1 {
+			return actions
+		}
+		// Calls must still be made
+		if stmt.Exps != nil {
+			for _, pair := range stmt.Exps.Pairs {
+				if !isPure(pair.Node) {
+					return actions
+				}
+			}
+		}
+	case *ast.FunctionStatement:
+		if binding == nil || binding.Kind != types.LocalFunctionBinding {
+			return actions
+		}
+	default:
+		return actions
+	}
+	rng := ast.Range(stmt)
+	// Assignments elsewhere would become assignments to a global
+	for _, ref := range file.Env.FindReferences(ident) {
+		if ref.Ident.Pos() < rng.Start || ref.Ident.End() > rng.End {
+			return actions
+		}
+	}
+	edit := protocol.TextEdit{Range: file.File.ToProtocolRange(removalRange(file.Src, rng))}
+	return append(actions, fix(fmt.Sprintf("Remove unused '%s'", name), []protocol.TextEdit{edit}))
+}
+
+// isPure returns true if evaluating the expression has no side effects. Calls,
+// operators and indexing can run arbitrary code through metamethods.
+func isPure(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.BooleanLiteral, *ast.FunctionExpression, *ast.Identifier, *ast.NilLiteral, *ast.NumberLiteral, *ast.StringLiteral, *ast.Vararg:
+		return true
+	case *ast.TableLiteral:
+		for _, pair := range expr.Fields.Pairs {
+			switch field := pair.Node.(type) {
+			case *ast.TableArrayField:
+				if !isPure(field.Expr) {
+					return false
+				}
+			case *ast.TableSimpleKeyField:
+				if !isPure(field.Expr) {
+					return false
+				}
+			case *ast.TableExpressionKeyField:
+				if !isPure(field.Name) || !isPure(field.Expr) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// removalRange extends the range of a statement to the whitespace around it,
+// and to its whole lines if there is nothing else on them.
+func removalRange(src string, rng token.Range) token.Range {
+	start, end := rng.Start, rng.End
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	lineStart := start
+	for lineStart > 0 && (src[lineStart-1] == ' ' || src[lineStart-1] == '\t') {
+		lineStart--
+	}
+	if (lineStart == 0 || src[lineStart-1] == '\n') && (end == len(src) || src[end] == '\n' || src[end] == '\r') {
+		start = lineStart
+		if strings.HasPrefix(src[end:], "\r\n") {
+			end += 2
+		} else if strings.HasPrefix(src[end:], "\n") {
+			end++
+		}
+	}
+	return token.Range{Start: start, End: end}
+}
@@ -0,0 +1,69 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// applyEdits returns the source with the edits of the code action applied.
+func applyEdits(file *File, action protocol.CodeAction) string {
+	src, lineBreaks := file.Src, file.File.LineBreaks
+	edits := action.Edit.Changes[file.Path]
+	// Later edits first, so that the earlier ranges stay valid
+	for i := len(edits) - 1; i >= 0; i-- {
+		src, lineBreaks, _ = applyChange(src, lineBreaks, protocol.TextDocumentContentChangeEvent{Range: &edits[i].Range, Text: edits[i].NewText})
+	}
+	return src
+}
+
+func TestUnusedFixes(t *testing.T) {
+	src := `local unused = 1
+local a, b = 1, 2
+local ok = os.remove("tmp")
+local config = { name = "a", [1] = function() end, { ... } }
+local function helper(x)
+  return helper(x)
+end
+local assigned
+assigned = 1
+for i = 1, 3 do
+  ::skip::
+end
+print(a)
+`
+	file := newTestFile(src)
+	actions := getCodeActions(file, newServer().getDiagnostics(file))
+	results := map[string]string{}
+	for _, action := range actions {
+		require.Len(t, action.Diagnostics, 1)
+		assert.Equal(t, protocol.CodeActionKindQuickFix, *action.Kind)
+		results[action.Title] = applyEdits(file, action)
+	}
+	titles := []string{}
+	for _, action := range actions {
+		titles = append(titles, action.Title)
+	}
+	assert.Equal(t, []string{
+		"Prefix 'unused' with '_'",
+		"Remove unused 'unused'",
+		"Prefix 'b' with '_'",
+		"Prefix 'ok' with '_'",
+		"Prefix 'config' with '_'",
+		"Remove unused 'config'",
+		"Prefix 'helper' with '_'",
+		"Remove unused 'helper'",
+		"Prefix 'assigned' with '_'",
+		"Prefix 'i' with '_'",
+		"Remove unused 'skip'",
+	}, titles)
+
+	assert.Equal(t, src[len("local unused = 1\n"):], results["Remove unused 'unused'"])
+	assert.Contains(t, results["Prefix 'helper' with '_'"], "local function _helper(x)\n  return _helper(x)")
+	assert.Contains(t, results["Remove unused 'config'"], "local ok = os.remove(\"tmp\")\nlocal function helper(x)")
+	assert.Contains(t, results["Remove unused 'helper'"], "local config = { name = \"a\", [1] = function() end, { ... } }\nlocal assigned\n")
+	assert.Contains(t, results["Prefix 'assigned' with '_'"], "local _assigned\n_assigned = 1")
+	assert.Contains(t, results["Remove unused 'skip'"], "for i = 1, 3 do\nend")
+}
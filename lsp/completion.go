@@ -0,0 +1,182 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/raiguard/luapls/util"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+type snippet struct {
+	label  string
+	detail string
+	body   string
+}
+
+var snippets = []snippet{
+	{"function", "function ... end", "function ${1:name}(${2})\n\t$0\nend"},
+	{"local function", "local function ... end", "local function ${1:name}(${2})\n\t$0\nend"},
+	{"for", "for i = ... do ... end", "for ${1:i} = ${2:1}, ${3:n} do\n\t$0\nend"},
+	{"for in", "for k, v in ... do ... end", "for ${1:k}, ${2:v} in ${3:pairs(${4:t})} do\n\t$0\nend"},
+}
+
+func (s *Server) textDocumentCompletion(ctx *glsp.Context, params *protocol.CompletionParams) (any, error) {
+	file := s.getFile(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+	return getCompletions(file, file.File.ToPos(params.Position), s.dialect), nil
+}
+
+// getCompletions returns the completion items for the given position. After a
+// `.` or `:` these are the fields of the indexed table, otherwise they are the
+// visible variables, keywords and snippets.
+func getCompletions(file *File, pos token.Pos, dialect token.Dialect) []protocol.CompletionItem {
+	items := []protocol.CompletionItem{}
+
+	// Find the start of the word being typed, and what precedes it
+	start := min(pos, len(file.Src))
+	for start > 0 && isIdentifierByte(file.Src[start-1]) {
+		start--
+	}
+	if isNumberStart(file.Src, start) {
+		// Inside a number
+		return items
+	}
+	trigger := start
+	for trigger > 0 && isSpace(file.Src[trigger-1]) {
+		trigger--
+	}
+
+	if trigger > 0 && (file.Src[trigger-1] == '.' || file.Src[trigger-1] == ':') {
+		if trigger > 1 && file.Src[trigger-2] == '.' {
+			// Concatenation
+			return items
+		}
+		isMethod := file.Src[trigger-1] == ':'
+		tbl, ok := getPrefixType(file, trigger-1).(*types.Table)
+		if !ok {
+			return items
+		}
+		for _, field := range tbl.Fields {
+			_, isFunction := field.Type.(*types.Function)
+			if isMethod && !isFunction {
+				continue
+			}
+			kind := protocol.CompletionItemKindField
+			if isFunction {
+				kind = protocol.CompletionItemKindMethod
+			}
+			items = append(items, protocol.CompletionItem{
+				Label:  field.Name,
+				Kind:   util.Ptr(kind),
+				Detail: util.Ptr(typeString(field.Type)),
+			})
+		}
+		sortCompletions(items)
+		return items
+	}
+
+	locals := file.Env.Scopes.Visible(pos)
+	for name, binding := range locals {
+		items = append(items, variableCompletion(name, file.Env.Types[binding.Ident]))
+	}
+	// Globals of the file and the standard library
+	for name, typ := range file.Env.GlobalTypes {
+		if _, ok := locals[name]; ok {
+			continue
+		}
+		items = append(items, variableCompletion(name, typ))
+	}
+	sortCompletions(items)
+
+	keywords := []string{}
+	for keyword := range token.Reserved {
+		if _, ok := dialect.Keyword(keyword); ok {
+			keywords = append(keywords, keyword)
+		}
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		items = append(items, protocol.CompletionItem{
+			Label: keyword,
+			Kind:  util.Ptr(protocol.CompletionItemKindKeyword),
+		})
+	}
+
+	for _, snippet := range snippets {
+		items = append(items, protocol.CompletionItem{
+			Label:            snippet.label,
+			Kind:             util.Ptr(protocol.CompletionItemKindSnippet),
+			Detail:           util.Ptr(snippet.detail),
+			InsertText:       util.Ptr(snippet.body),
+			InsertTextFormat: util.Ptr(protocol.InsertTextFormatSnippet),
+		})
+	}
+
+	return items
+}
+
+// getPrefixType returns the type of the outermost expression that ends at
+// pos, such as `foo.bar` in `foo.bar.baz`.
+func getPrefixType(file *File, pos token.Pos) types.Type {
+	end := pos
+	for end > 0 && isSpace(file.Src[end-1]) {
+		end--
+	}
+	if end == 0 {
+		return nil
+	}
+	nodePath := ast.GetNode(&file.File.Block, end-1)
+	nodes := append(nodePath.Parents, nodePath.Node)
+	for _, node := range nodes {
+		if node == nil || node.End() != end {
+			continue
+		}
+		if typ, ok := file.Env.Types[node]; ok && typ != nil {
+			return typ
+		}
+	}
+	return nil
+}
+
+func variableCompletion(name string, typ types.Type) protocol.CompletionItem {
+	kind := protocol.CompletionItemKindVariable
+	if _, ok := typ.(*types.Function); ok {
+		kind = protocol.CompletionItemKindFunction
+	}
+	return protocol.CompletionItem{
+		Label:  name,
+		Kind:   util.Ptr(kind),
+		Detail: util.Ptr(typeString(typ)),
+	}
+}
+
+func typeString(typ types.Type) string {
+	if typ == nil {
+		typ = &types.Unknown{}
+	}
+	return typ.String()
+}
+
+func sortCompletions(items []protocol.CompletionItem) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || b >= 0x80
+}
+
+// isNumberStart reports whether the word starting at start is a number.
+func isNumberStart(src string, start int) bool {
+	return start < len(src) && '0' <= src[start] && src[start] <= '9'
+}
+
+func isSpace(b byte) bool {
+	return strings.IndexByte(" \t\r\n", b) >= 0
+}
@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/stretchr/testify/assert"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func newTestFile(src string) *File {
+	parserFile := parser.New(src).ParseFile()
+	file := &File{File: &parserFile, Src: src}
+	file.Env = types.NewEnvironment(file.File)
+	file.Env.ResolveTypes()
+	return file
+}
+
+func completionLabels(items []protocol.CompletionItem) []string {
+	labels := []string{}
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestCompletion(t *testing.T) {
+	src := "local foo = 1\nbar = \"baz\"\nlocal function add(a, b)\n  \nend\n"
+	file := newTestFile(src)
+	items := getCompletions(file, strings.Index(src, "  \n")+2, token.Lua54)
+	labels := completionLabels(items)
+
+	assert.Subset(t, labels, []string{"a", "b", "add", "bar", "foo", "local", "function", "for in", "print", "string"})
+	assert.Equal(t, "number", *items[indexOf(labels, "foo")].Detail)
+	assert.Equal(t, "string", *items[indexOf(labels, "bar")].Detail)
+	assert.Equal(t, protocol.InsertTextFormatSnippet, *items[indexOf(labels, "for in")].InsertTextFormat)
+
+	// Later locals are not visible
+	items = getCompletions(file, 0, token.Lua51)
+	labels = completionLabels(items)
+	assert.NotContains(t, labels, "foo")
+	assert.NotContains(t, labels, "goto")
+
+	// Numbers
+	assert.Empty(t, getCompletions(file, strings.Index(src, "1")+1, token.Lua54))
+}
+
+func TestFieldCompletion(t *testing.T) {
+	src := "local tbl\nprint(tbl.)\nprint(tbl:f)"
+	file := newTestFile(src)
+	tbl := &types.Table{Fields: []types.NameAndType{
+		{Name: "value", Type: &types.Number{}},
+		{Name: "method", Type: &types.Function{}},
+	}}
+	ast.Walk(&file.File.Block, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok && ident.Token.Literal == "tbl" {
+			file.Env.Types[node] = tbl
+		}
+		return true
+	})
+
+	items := getCompletions(file, strings.Index(src, ".")+1, token.Lua54)
+	assert.Equal(t, []string{"method", "value"}, completionLabels(items))
+	assert.Equal(t, "number", *items[1].Detail)
+
+	items = getCompletions(file, len(src)-1, token.Lua54)
+	assert.Equal(t, []string{"method"}, completionLabels(items))
+}
+
+func indexOf(labels []string, label string) int {
+	for i, l := range labels {
+		if l == label {
+			return i
+		}
+	}
+	return -1
+}
@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/types"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
@@ -16,13 +17,91 @@ func (s *Server) textDocumentDefinition(ctx *glsp.Context, params *protocol.Defi
 
 	nodePath := ast.GetNode(&file.File.Block, pos)
 
-	def := file.Env.FindDefinition(nodePath)
-	if def == nil {
-		return nil, nil
+	if def := file.Env.FindDefinition(nodePath); def != nil {
+		return &protocol.Location{
+			URI:   params.TextDocument.URI,
+			Range: file.File.ToProtocolRange(ast.Range(def)),
+		}, nil
+	}
+
+	return s.findModuleDefinition(file, nodePath), nil
+}
+
+// findModuleDefinition returns the location of the module loaded by the
+// require argument at the end of the path, or of the definition of the table
+// field at the end of the path, which may be in another file.
+func (s *Server) findModuleDefinition(file *File, nodePath ast.NodePath) *protocol.Location {
+	switch node := nodePath.Node.(type) {
+	case *ast.StringLiteral:
+		name, ok := file.Env.Requires[node]
+		if !ok {
+			return nil
+		}
+		module := s.findModule(name)
+		if module == nil {
+			return nil
+		}
+		return &protocol.Location{URI: module.Path}
+	case *ast.Identifier:
+		if len(nodePath.Parents) == 0 {
+			return nil
+		}
+		index, ok := nodePath.Parents[len(nodePath.Parents)-1].(*ast.IndexExpression)
+		if !ok || index.Inner != node {
+			return nil
+		}
+		tbl, ok := file.Env.Types[index.Prefix].(*types.Table)
+		if !ok {
+			return nil
+		}
+		for _, field := range tbl.Fields {
+			if field.Name != node.Token.Literal || field.Def == nil {
+				continue
+			}
+			def := definitionName(field.Def)
+			owner := s.fileOf(file, def)
+			if owner == nil {
+				return nil
+			}
+			return &protocol.Location{
+				URI:   owner.Path,
+				Range: owner.File.ToProtocolRange(ast.Range(def)),
+			}
+		}
+	}
+	return nil
+}
+
+// definitionName returns the name that a field definition assigns, if it has
+// one.
+func definitionName(def ast.Node) ast.Node {
+	switch def := def.(type) {
+	case *ast.FunctionStatement:
+		return def.Name
+	case *ast.TableSimpleKeyField:
+		return &def.Name
 	}
+	return def
+}
 
-	return &protocol.Location{
-		URI:   params.TextDocument.URI,
-		Range: file.File.ToProtocolRange(ast.Range(def)),
-	}, nil
+// fileOf returns the file that contains node, searching the given file first.
+func (s *Server) fileOf(file *File, node ast.Node) *File {
+	contains := func(file *File) bool {
+		path := ast.GetNode(&file.File.Block, node.Pos())
+		for _, parent := range append(path.Parents, path.Node) {
+			if parent == node {
+				return true
+			}
+		}
+		return false
+	}
+	if contains(file) {
+		return file
+	}
+	for _, uri := range s.sortedURIs() {
+		if other := s.files[uri]; other != file && contains(other) {
+			return other
+		}
+	}
+	return nil
 }
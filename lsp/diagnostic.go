@@ -1,29 +1,140 @@
 package lsp
 
 import (
+	"math"
+	"slices"
+
+	"github.com/raiguard/luapls/lua/doc"
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
 	"github.com/raiguard/luapls/util"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 func (s *Server) publishDiagnostics(ctx *glsp.Context, file *File) {
+	if file.library && !file.open {
+		return
+	}
+	ctx.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+		URI:         file.Path,
+		Diagnostics: s.getDiagnostics(file),
+	})
+}
+
+// getDiagnostics returns the parse and type errors of the file, except for
+// those that are disabled by the settings or by `---@diagnostic` comments.
+func (s *Server) getDiagnostics(file *File) []protocol.Diagnostic {
+	suppressions := findSuppressions(file.File)
 	diagnostics := []protocol.Diagnostic{}
-	for _, err := range file.File.Errors {
-		diagnostics = append(diagnostics, protocol.Diagnostic{
+	for _, err := range append(slices.Clone(file.File.Errors), file.Env.Errors...) {
+		if isSuppressed(suppressions, err.Code, err.Range.Start) {
+			continue
+		}
+		severity := s.diagnosticSeverity(err.Code, protocol.DiagnosticSeverity(err.Severity))
+		if severity == nil {
+			continue
+		}
+		diagnostic := protocol.Diagnostic{
 			Range:    file.File.ToProtocolRange(err.Range),
-			Severity: util.Ptr(protocol.DiagnosticSeverityError),
+			Severity: severity,
+			Code:     &protocol.IntegerOrString{Value: err.Code},
+			Source:   util.Ptr(LS_NAME),
 			Message:  err.Message,
-		})
+		}
+		for _, related := range err.Related {
+			diagnostic.RelatedInformation = append(diagnostic.RelatedInformation, protocol.DiagnosticRelatedInformation{
+				Location: protocol.Location{URI: file.Path, Range: file.File.ToProtocolRange(related.Range)},
+				Message:  related.Message,
+			})
+		}
+		for _, tag := range err.Tags {
+			diagnostic.Tags = append(diagnostic.Tags, protocol.DiagnosticTag(tag))
+		}
+		diagnostics = append(diagnostics, diagnostic)
 	}
-	for _, err := range file.Env.Errors {
-		diagnostics = append(diagnostics, protocol.Diagnostic{
-			Range:    file.File.ToProtocolRange(err.Range),
-			Severity: util.Ptr(protocol.DiagnosticSeverityWarning),
-			Message:  err.Message,
-		})
+	return diagnostics
+}
+
+// suppression is a range of the file in which diagnostics with the code are
+// not reported. An empty code matches every diagnostic.
+type suppression struct {
+	code string
+	rng  token.Range
+}
+
+// findSuppressions returns the ranges that are disabled by `---@diagnostic`
+// comments. `disable` lasts until a matching `enable` or the end of the file.
+func findSuppressions(file *parser.File) []suppression {
+	suppressions := []suppression{}
+	disabled := map[string]token.Pos{} // The start of each open `disable`
+	for _, comment := range file.Comments() {
+		parsed := doc.Parse([]token.Token{comment})
+		for _, annotation := range doc.Find[*doc.Diagnostic](parsed) {
+			codes := []string{""}
+			if len(annotation.Codes) > 0 {
+				codes = codes[:0]
+				for _, code := range annotation.Codes {
+					codes = append(codes, code.Name)
+				}
+			}
+			line := int(file.ToProtocolPos(comment.Pos).Line)
+			switch annotation.Action {
+			case "disable-line":
+				for _, code := range codes {
+					suppressions = append(suppressions, suppression{code, lineRange(file, line)})
+				}
+			case "disable-next-line":
+				for _, code := range codes {
+					suppressions = append(suppressions, suppression{code, lineRange(file, line+1)})
+				}
+			case "disable":
+				for _, code := range codes {
+					if _, ok := disabled[code]; !ok {
+						disabled[code] = comment.End()
+					}
+				}
+			case "enable":
+				if len(annotation.Codes) == 0 {
+					codes = codes[:0]
+					for code := range disabled {
+						codes = append(codes, code)
+					}
+				}
+				for _, code := range codes {
+					if start, ok := disabled[code]; ok {
+						suppressions = append(suppressions, suppression{code, token.Range{Start: start, End: comment.Pos}})
+						delete(disabled, code)
+					}
+				}
+			}
+		}
 	}
-	ctx.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
-		URI:         file.Path,
-		Diagnostics: diagnostics,
-	})
+	for code, start := range disabled {
+		suppressions = append(suppressions, suppression{code, token.Range{Start: start, End: math.MaxInt}})
+	}
+	return suppressions
+}
+
+// lineRange returns the range of the given line, including its line break.
+func lineRange(file *parser.File, line int) token.Range {
+	rng := token.Range{Start: 0, End: math.MaxInt}
+	if line > 0 && line <= len(file.LineBreaks) {
+		rng.Start = file.LineBreaks[line-1] + 1
+	} else if line > len(file.LineBreaks) {
+		rng.Start = math.MaxInt
+	}
+	if line < len(file.LineBreaks) {
+		rng.End = file.LineBreaks[line] + 1
+	}
+	return rng
+}
+
+func isSuppressed(suppressions []suppression, code string, pos token.Pos) bool {
+	for _, suppression := range suppressions {
+		if (suppression.code == "" || suppression.code == code) && suppression.rng.Start <= pos && pos < suppression.rng.End {
+			return true
+		}
+	}
+	return false
 }
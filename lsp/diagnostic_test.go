@@ -0,0 +1,73 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func diagnosticCodes(diagnostics []protocol.Diagnostic) []string {
+	codes := []string{}
+	for _, diagnostic := range diagnostics {
+		codes = append(codes, diagnostic.Code.Value.(string))
+	}
+	return codes
+}
+
+func TestDiagnostics(t *testing.T) {
+	src := `---@type number
+local x = 1
+x = "a"
+---@deprecated Use new instead
+local function old() end
+old()
+break
+`
+	s := newServer()
+	diagnostics := s.getDiagnostics(newTestFile(src))
+	require.Equal(t, []string{"assign-type-mismatch", "deprecated", "break-outside-loop", "unused-local"}, diagnosticCodes(diagnostics))
+
+	assert.Equal(t, protocol.DiagnosticSeverityWarning, *diagnostics[0].Severity)
+	require.Len(t, diagnostics[0].RelatedInformation, 1)
+	assert.Equal(t, uint32(1), diagnostics[0].RelatedInformation[0].Location.Range.Start.Line)
+
+	assert.Equal(t, "'old' is deprecated: Use new instead", diagnostics[1].Message)
+	assert.Equal(t, protocol.DiagnosticSeverityHint, *diagnostics[1].Severity)
+	assert.Equal(t, []protocol.DiagnosticTag{protocol.DiagnosticTagDeprecated}, diagnostics[1].Tags)
+
+	assert.Equal(t, protocol.DiagnosticSeverityError, *diagnostics[2].Severity)
+
+	assert.Equal(t, "Unused local 'x'", diagnostics[3].Message)
+	assert.Equal(t, []protocol.DiagnosticTag{protocol.DiagnosticTagUnnecessary}, diagnostics[3].Tags)
+
+	// Severities from the settings
+	s.applySettings(map[string]any{"diagnostics": map[string]string{"deprecated": "off", "break-outside-loop": "warning"}})
+	diagnostics = s.getDiagnostics(newTestFile(src))
+	require.Equal(t, []string{"assign-type-mismatch", "break-outside-loop", "unused-local"}, diagnosticCodes(diagnostics))
+	assert.Equal(t, protocol.DiagnosticSeverityWarning, *diagnostics[1].Severity)
+}
+
+func TestDiagnosticComments(t *testing.T) {
+	src := `---@diagnostic disable-next-line: undefined-global
+print(a)
+print(b) ---@diagnostic disable-line
+print(c)
+---@diagnostic disable: undefined-global, call-non-callable
+print(d)
+local e = 1
+e()
+---@diagnostic enable: undefined-global
+print(f)
+e()
+--- @diagnostic disable
+print(g
+`
+	diagnostics := newServer().getDiagnostics(newTestFile(src))
+	messages := []string{}
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.Message)
+	}
+	assert.Equal(t, []string{"Undefined global 'c'", "Undefined global 'f'"}, messages)
+}
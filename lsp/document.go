@@ -1,22 +1,21 @@
 package lsp
 
 import (
-	"os"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
 	"github.com/raiguard/luapls/lua/types"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-// TODO: Incremental changes
 func (s *Server) textDocumentDidOpen(ctx *glsp.Context, params *protocol.DidOpenTextDocumentParams) error {
-	file := s.createFile(params.TextDocument.URI)
-	if file == nil {
-		return nil
-	}
+	file := s.createFile(params.TextDocument.URI, params.TextDocument.Text)
 	s.publishDiagnostics(ctx, file)
+	s.checkDependents(ctx, file)
 	return nil
 }
 
@@ -25,42 +24,142 @@ func (s *Server) textDocumentDidChange(ctx *glsp.Context, params *protocol.DidCh
 	if file == nil {
 		return nil
 	}
+	before := time.Now()
 	for _, change := range params.ContentChanges {
-		if change, ok := change.(protocol.TextDocumentContentChangeEventWhole); ok {
-			before := time.Now()
-			newFile := parser.New(change.Text).ParseFile()
-			file.File = &newFile
-			file.Env = types.NewEnvironment(&newFile)
-			file.Env.ResolveTypes()
-			s.log.Debugf("Reparse duration: %s", time.Since(before).String())
-			s.publishDiagnostics(ctx, file)
+		switch change := change.(type) {
+		case protocol.TextDocumentContentChangeEventWhole:
+			// Later ranged changes in the batch need the new line breaks
+			file.Src = change.Text
+			parserFile := parser.NewWithDialect(file.Src, s.dialect).ParseFile()
+			file.File = &parserFile
+		case protocol.TextDocumentContentChangeEvent:
+			var edit parser.Edit
+			file.Src, file.File.LineBreaks, edit = applyChange(file.Src, file.File.LineBreaks, change)
+			reparsed := parser.Reparse(file.File, file.Src, edit)
+			file.File = &reparsed
 		}
 	}
+	s.checkFile(file)
+	s.log.Debugf("Reparse duration: %s", time.Since(before).String())
+	s.publishDiagnostics(ctx, file)
+	s.checkDependents(ctx, file)
 	return nil
 }
 
 func (s *Server) textDocumentDidClose(ctx *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
-	s.files[params.TextDocument.URI] = nil
-	return nil
-}
-
-func (s *Server) createFile(uri protocol.URI) *File {
-	path, err := uriToPath(uri)
-	if err != nil {
-		s.log.Errorf("%s", err)
+	uri := params.TextDocument.URI
+	file := s.files[uri]
+	if file == nil {
 		return nil
 	}
-	src, err := os.ReadFile(path)
-	if err != nil {
-		s.log.Errorf("Failed to parse file %s: %s", uri, err)
-		return nil
+	// Workspace files go back to their contents on disk
+	delete(s.files, uri)
+	if path, err := uriToPath(uri); err == nil && s.isIndexed(path) {
+		if loaded := s.loadFile(path); loaded != nil {
+			file = loaded
+			s.checkFile(file)
+			s.publishDiagnostics(ctx, file)
+		}
 	}
+	s.checkDependents(ctx, file)
+	return nil
+}
+
+func (s *Server) createFile(uri protocol.URI, src string) *File {
 	timer := time.Now()
-	parserFile := parser.New(string(src)).ParseFile()
-	file := &File{File: &parserFile, Env: types.NewEnvironment(&parserFile), Path: uri}
-	file.Env.ResolveTypes()
+	file := &File{Path: uri, Src: src, open: true}
 	s.files[uri] = file
+	s.parseFile(file)
 	s.log.Debugf("Parsed file '%s' in %s", uri, time.Since(timer).String())
 
 	return file
 }
+
+// parseFile parses and type checks the file's source using the current dialect.
+func (s *Server) parseFile(file *File) {
+	parserFile := parser.NewWithDialect(file.Src, s.dialect).ParseFile()
+	file.File = &parserFile
+	s.checkFile(file)
+}
+
+// checkFile type checks the file's current syntax tree. The modules that it
+// requires and the files that define its globals are checked first.
+func (s *Server) checkFile(file *File) {
+	file.checking = true
+	file.dependencies = map[string]bool{}
+	file.Env = types.NewEnvironment(file.File)
+	file.Env.Options = s.options
+	file.Env.Require = func(name string) types.Type {
+		return s.require(file, name)
+	}
+	file.Env.GlobalType = func(name string) types.Type {
+		return s.globalType(file, name)
+	}
+	file.Env.ResolveTypes()
+	file.checking = false
+	file.checked = true
+}
+
+// applyChange applies a ranged content change to src, and returns the new
+// source, its updated line breaks and the edit in byte offsets. Subsequent changes in the same
+// notification are relative to the result, so the line breaks must be kept
+// current without waiting for a reparse.
+func applyChange(src string, lineBreaks []int, change protocol.TextDocumentContentChangeEvent) (string, []int, parser.Edit) {
+	start := offsetAt(src, lineBreaks, change.Range.Start)
+	end := offsetAt(src, lineBreaks, change.Range.End)
+	if end < start {
+		start, end = end, start
+	}
+	newSrc := src[:start] + change.Text + src[end:]
+
+	delta := len(change.Text) - (end - start)
+	newBreaks := make([]int, 0, len(lineBreaks)+strings.Count(change.Text, "\n"))
+	for _, lineBreak := range lineBreaks {
+		if lineBreak >= start {
+			break
+		}
+		newBreaks = append(newBreaks, lineBreak)
+	}
+	for i := 0; i < len(change.Text); i++ {
+		if change.Text[i] == '\n' {
+			newBreaks = append(newBreaks, start+i)
+		}
+	}
+	for _, lineBreak := range lineBreaks {
+		if lineBreak >= end {
+			newBreaks = append(newBreaks, lineBreak+delta)
+		}
+	}
+
+	return newSrc, newBreaks, parser.Edit{Range: token.Range{Start: start, End: end}, Text: change.Text}
+}
+
+// offsetAt converts a protocol position to a byte offset into src. The
+// character of the position counts UTF-16 code units. Positions past the end
+// of a line are clamped to the end of that line, as the LSP specification
+// requires.
+func offsetAt(src string, lineBreaks []int, position protocol.Position) int {
+	line := int(position.Line)
+	if line > len(lineBreaks) {
+		return len(src)
+	}
+	lineStart := 0
+	if line > 0 {
+		lineStart = lineBreaks[line-1] + 1
+	}
+	lineEnd := len(src)
+	if line < len(lineBreaks) {
+		lineEnd = lineBreaks[line]
+	}
+	offset := lineStart
+	for units := int(position.Character); units > 0 && offset < lineEnd; {
+		r, width := utf8.DecodeRuneInString(src[offset:lineEnd])
+		units--
+		if r > 0xFFFF {
+			// Characters outside the basic plane are a UTF-16 surrogate pair
+			units--
+		}
+		offset += width
+	}
+	return offset
+}
@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/raiguard/luapls/lua/lexer"
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+type rangedEdit struct {
+	startLine, startChar, endLine, endChar uint32
+	text                                   string
+}
+
+func TestApplyChanges(t *testing.T) {
+	tests := []struct {
+		label    string
+		input    string
+		edits    []rangedEdit
+		expected string
+	}{
+		{
+			"insert",
+			"local foo = 1\nprint(foo)\n",
+			[]rangedEdit{{1, 6, 1, 6, "bar, "}},
+			"local foo = 1\nprint(bar, foo)\n",
+		},
+		{
+			"delete across lines",
+			"local foo = 1\nlocal bar = 2\nprint(foo)\n",
+			[]rangedEdit{{0, 12, 1, 12, ""}},
+			"local foo = 2\nprint(foo)\n",
+		},
+		{
+			"replace with newlines",
+			"if a then b() end",
+			[]rangedEdit{{0, 9, 0, 14, "\n  b()\n"}},
+			"if a then\n  b()\nend",
+		},
+		{
+			"typing sequence",
+			"",
+			[]rangedEdit{
+				{0, 0, 0, 0, "l"},
+				{0, 1, 0, 1, "ocal x"},
+				{0, 7, 0, 7, "\n"},
+				{1, 0, 1, 0, "print(x)"},
+				{0, 6, 0, 7, "y"},
+				{1, 6, 1, 7, "y"},
+			},
+			"local y\nprint(y)",
+		},
+		{
+			"multibyte characters",
+			"print(\"é😀\", x)\n",
+			[]rangedEdit{{0, 10, 0, 10, "!"}, {0, 14, 0, 15, "y"}},
+			"print(\"é😀!\", y)\n",
+		},
+		{
+			"end of last line",
+			"a = 1\nb = 2",
+			[]rangedEdit{{1, 5, 1, 5, "3"}, {1, 99, 1, 99, "\n"}},
+			"a = 1\nb = 23\n",
+		},
+		{
+			"delete everything",
+			"a = 1\nb = 2\n",
+			[]rangedEdit{{0, 0, 2, 0, ""}},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			src := test.input
+			_, lineBreaks := lexer.Run(src)
+			for _, edit := range test.edits {
+				oldSrc := src
+				var byteEdit parser.Edit
+				src, lineBreaks, byteEdit = applyChange(src, lineBreaks, protocol.TextDocumentContentChangeEvent{
+					Range: &protocol.Range{
+						Start: protocol.Position{Line: edit.startLine, Character: edit.startChar},
+						End:   protocol.Position{Line: edit.endLine, Character: edit.endChar},
+					},
+					Text: edit.text,
+				})
+				assert.Equal(t, src, oldSrc[:byteEdit.Range.Start]+byteEdit.Text+oldSrc[byteEdit.Range.End:])
+				_, expectedBreaks := lexer.Run(src)
+				assert.Equal(t, expectedBreaks, lineBreaks)
+			}
+			assert.Equal(t, test.expected, src)
+		})
+	}
+}
+
+func TestDidChange(t *testing.T) {
+	s := newServer()
+	s.isInitialized = true
+	ctx := &glsp.Context{Notify: func(method string, params any) {}}
+	uri := "file:///test.lua"
+	assert.NoError(t, s.textDocumentDidOpen(ctx, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Text: "local a = 1\nlocal b = 2\nprint(a, b)\n"},
+	}))
+	change := func(changes ...any) string {
+		assert.NoError(t, s.textDocumentDidChange(ctx, &protocol.DidChangeTextDocumentParams{
+			TextDocument:   protocol.VersionedTextDocumentIdentifier{TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri}},
+			ContentChanges: changes,
+		}))
+		file := s.files[uri]
+		_, lineBreaks := lexer.Run(file.Src)
+		assert.Equal(t, lineBreaks, file.File.LineBreaks)
+		return file.Src
+	}
+	ranged := func(line, char uint32, text string) protocol.TextDocumentContentChangeEvent {
+		position := protocol.Position{Line: line, Character: char}
+		return protocol.TextDocumentContentChangeEvent{Range: &protocol.Range{Start: position, End: position}, Text: text}
+	}
+
+	// Ranged changes after a whole change are relative to the new text
+	assert.Equal(t, "xy", change(protocol.TextDocumentContentChangeEventWhole{Text: "x"}, ranged(1, 0, "y")))
+	assert.Equal(t, "local c = 1\nprint(c)\n", change(
+		protocol.TextDocumentContentChangeEventWhole{Text: "local c = 1\n"},
+		ranged(1, 0, "print(c)\n"),
+	))
+	assert.Empty(t, s.files[uri].File.Errors)
+}
@@ -15,18 +15,27 @@ func (s *Server) textDocumentHighlight(ctx *glsp.Context, params *protocol.Docum
 	if nodePath.Node == nil {
 		return nil, nil
 	}
-	highlights := []protocol.DocumentHighlight{}
 	// TODO: Labels
-	_, ok := nodePath.Node.(ast.LeafNode)
+	ident, ok := nodePath.Node.(*ast.Identifier)
 	if !ok {
 		return nil, nil
 	}
-	// TODO: References
-	highlights = append(highlights, protocol.DocumentHighlight{Range: file.File.ToProtocolRange(ast.Range(nodePath.Node))})
 
-	def := file.Env.FindDefinition(nodePath)
-	if def != nil {
-		highlights = append(highlights, protocol.DocumentHighlight{Range: file.File.ToProtocolRange(ast.Range(def))})
+	refs := file.Env.FindReferences(ident)
+	if len(refs) == 0 {
+		return []protocol.DocumentHighlight{{Range: file.File.ToProtocolRange(ast.Range(ident))}}, nil
+	}
+
+	highlights := []protocol.DocumentHighlight{}
+	for _, ref := range refs {
+		kind := protocol.DocumentHighlightKindRead
+		if ref.Write {
+			kind = protocol.DocumentHighlightKindWrite
+		}
+		highlights = append(highlights, protocol.DocumentHighlight{
+			Range: file.File.ToProtocolRange(ast.Range(ref.Ident)),
+			Kind:  &kind,
+		})
 	}
 
 	return highlights, nil
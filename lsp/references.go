@@ -0,0 +1,32 @@
+package lsp
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) textDocumentReferences(ctx *glsp.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+	file := s.getFile(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+	nodePath := ast.GetNode(&file.File.Block, file.File.ToPos(params.Position))
+	ident, ok := nodePath.Node.(*ast.Identifier)
+	if !ok {
+		return nil, nil
+	}
+
+	locations := []protocol.Location{}
+	for _, ref := range file.Env.FindReferences(ident) {
+		if ref.Ident == file.Env.Scopes.Uses[ident].Ident && !params.Context.IncludeDeclaration {
+			continue
+		}
+		locations = append(locations, protocol.Location{
+			URI:   params.TextDocument.URI,
+			Range: file.File.ToProtocolRange(ast.Range(ref.Ident)),
+		})
+	}
+
+	return locations, nil
+}
@@ -0,0 +1,109 @@
+package lsp
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/lexer"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func (s *Server) textDocumentPrepareRename(ctx *glsp.Context, params *protocol.PrepareRenameParams) (any, error) {
+	file := s.getFile(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+	ident, _, err := getRenameTarget(file, file.File.ToPos(params.Position))
+	if err != nil {
+		return nil, err
+	}
+	return file.File.ToProtocolRange(ast.Range(ident)), nil
+}
+
+func (s *Server) textDocumentRename(ctx *glsp.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+	file := s.getFile(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+	edits, err := getRenameEdits(file, file.File.ToPos(params.Position), params.NewName)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{params.TextDocument.URI: edits},
+	}, nil
+}
+
+// getRenameTarget returns the identifier at pos and the references to its
+// variable, if it can be renamed.
+func getRenameTarget(file *File, pos token.Pos) (*ast.Identifier, []types.Reference, error) {
+	ident, ok := ast.GetNode(&file.File.Block, pos).Node.(*ast.Identifier)
+	if !ok {
+		return nil, nil, fmt.Errorf("No variable at this position")
+	}
+	binding := file.Env.Scopes.Uses[ident]
+	if binding == nil {
+		return nil, nil, fmt.Errorf("Only local variables can be renamed")
+	}
+	if binding.Kind == types.SelfBinding {
+		return nil, nil, fmt.Errorf("'self' cannot be renamed")
+	}
+	refs := file.Env.FindReferences(ident)
+	return ident, refs, nil
+}
+
+// getRenameEdits returns the edits that rename the variable at pos to newName.
+func getRenameEdits(file *File, pos token.Pos, newName string) ([]protocol.TextEdit, error) {
+	ident, refs, err := getRenameTarget(file, pos)
+	if err != nil {
+		return nil, err
+	}
+	oldName := ident.Token.Literal
+	if newName == oldName {
+		return []protocol.TextEdit{}, nil
+	}
+	if _, ok := file.File.Dialect().Keyword(newName); ok {
+		return nil, fmt.Errorf("'%s' is a keyword", newName)
+	}
+	if !isValidIdentifier(newName) {
+		return nil, fmt.Errorf("'%s' is not a valid identifier", newName)
+	}
+
+	// Another variable with the new name would shadow or capture the renamed one
+	def := refs[0].Ident
+	for _, ref := range refs {
+		if other := file.Env.Scopes.Lookup(newName, ref.Ident.Pos()); other != nil && other.Ident != def {
+			return nil, fmt.Errorf("'%s' would conflict with the local variable on line %d", newName, file.File.ToProtocolRange(ast.Range(other.Ident)).Start.Line+1)
+		}
+	}
+	// The renamed variable would capture uses of a global with the new name
+	for _, ref := range file.Env.Globals[newName] {
+		if binding := file.Env.Scopes.Lookup(oldName, ref.Ident.Pos()); binding != nil && binding.Ident == def {
+			return nil, fmt.Errorf("'%s' would capture the global variable on line %d", newName, file.File.ToProtocolRange(ast.Range(ref.Ident)).Start.Line+1)
+		}
+	}
+
+	edits := []protocol.TextEdit{}
+	for _, ref := range refs {
+		edits = append(edits, protocol.TextEdit{
+			Range:   file.File.ToProtocolRange(ast.Range(ref.Ident)),
+			NewText: newName,
+		})
+	}
+	return edits, nil
+}
+
+// isValidIdentifier returns true if the lexer would read name as a single
+// identifier.
+func isValidIdentifier(name string) bool {
+	for i, r := range name {
+		if !lexer.IsIdentifier(r) || i == 0 && unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return name != ""
+}
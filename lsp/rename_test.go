@@ -0,0 +1,61 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	src := `local foo = 1
+local function add(a, b)
+  return a + b + foo
+end
+print(add(foo, 2))
+`
+	file := newTestFile(src)
+	edits, err := getRenameEdits(file, strings.Index(src, "foo"), "bar")
+	require.NoError(t, err)
+	require.Len(t, edits, 3)
+	for _, edit := range edits {
+		assert.Equal(t, "bar", edit.NewText)
+	}
+	assert.Equal(t, uint32(2), edits[1].Range.Start.Line)
+	assert.Equal(t, uint32(17), edits[1].Range.Start.Character)
+
+	_, err = getRenameEdits(file, strings.Index(src, "a,"), "b")
+	assert.ErrorContains(t, err, "would conflict")
+	_, err = getRenameEdits(file, strings.Index(src, "a,"), "foo")
+	assert.ErrorContains(t, err, "would conflict")
+	_, err = getRenameEdits(file, strings.Index(src, "foo"), "print")
+	assert.ErrorContains(t, err, "would capture")
+	_, err = getRenameEdits(file, strings.Index(src, "foo"), "end")
+	assert.ErrorContains(t, err, "keyword")
+	_, err = getRenameEdits(file, strings.Index(src, "foo"), "1foo")
+	assert.ErrorContains(t, err, "not a valid identifier")
+	_, err = getRenameEdits(file, strings.Index(src, "print"), "echo")
+	assert.ErrorContains(t, err, "Only local variables")
+	_, err = getRenameEdits(file, strings.Index(src, "foo"), "goto")
+	assert.ErrorContains(t, err, "keyword")
+	edits, err = getRenameEdits(file, strings.Index(src, "foo"), "größe")
+	require.NoError(t, err)
+	assert.Equal(t, "größe", edits[0].NewText)
+}
+
+func TestRenameDialect(t *testing.T) {
+	src := "local foo = 1\nprint(foo)\n"
+	parserFile := parser.NewWithDialect(src, token.Lua51).ParseFile()
+	file := &File{File: &parserFile, Src: src}
+	file.Env = types.NewEnvironment(file.File)
+	file.Env.ResolveTypes()
+
+	// goto is not a keyword in Lua 5.1
+	edits, err := getRenameEdits(file, strings.Index(src, "foo"), "goto")
+	require.NoError(t, err)
+	assert.Len(t, edits, 2)
+}
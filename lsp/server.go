@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
 	"github.com/raiguard/luapls/lua/types"
 	"github.com/raiguard/luapls/util"
 	"github.com/tliron/commonlog"
@@ -19,36 +20,58 @@ type File struct {
 	File *parser.File
 	Env  types.Environment
 	Path string
+	Src  string
+
+	dependencies map[string]bool // The URIs of the modules that the file requires
+	checking     bool
+	checked      bool
+	open         bool // The file is open in the client, which owns its contents
+	library      bool // The file is in a library, and is not diagnosed
 }
 
 // Type Server contains the state for the LSP session.
 type Server struct {
-	files    map[string]*File
-	handler  protocol.Handler
-	log      commonlog.Logger
-	rootPath string
-	server   *glspserv.Server
+	dialect     token.Dialect
+	options     types.Options
+	files       map[string]*File // Open files and the files of the workspace
+	handler     protocol.Handler
+	log         commonlog.Logger
+	packagePath string
+	rootPath    string
+	server      *glspserv.Server
+
+	clientSettings  Settings
+	projectSettings Settings
+	settings        Settings // The client settings applied over the project settings
 
 	isInitialized bool
+	logLevel      int
+	watchFiles    bool // The client can watch files for the server
 }
 
 func Run(logLevel int) {
 	commonlog.Configure(logLevel, util.Ptr("/tmp/luapls.log"))
 
-	s := Server{
-		files: map[string]*File{},
-	}
+	s := newServer()
+	s.logLevel = logLevel
 
 	s.handler.Initialize = s.initialize
 	s.handler.Initialized = s.initialized
 	s.handler.Shutdown = s.shutdown
 	s.handler.SetTrace = s.setTrace
+	s.handler.WorkspaceDidChangeConfiguration = s.workspaceDidChangeConfiguration
+	s.handler.WorkspaceDidChangeWatchedFiles = s.workspaceDidChangeWatchedFiles
 	s.handler.TextDocumentDidOpen = s.textDocumentDidOpen
 	s.handler.TextDocumentDidChange = s.textDocumentDidChange
 	s.handler.TextDocumentDidClose = s.textDocumentDidClose
+	s.handler.TextDocumentCompletion = s.textDocumentCompletion
 	s.handler.TextDocumentDocumentHighlight = s.textDocumentHighlight
 	s.handler.TextDocumentHover = s.textDocumentHover
 	s.handler.TextDocumentDefinition = s.textDocumentDefinition
+	s.handler.TextDocumentReferences = s.textDocumentReferences
+	s.handler.TextDocumentPrepareRename = s.textDocumentPrepareRename
+	s.handler.TextDocumentRename = s.textDocumentRename
+	s.handler.TextDocumentCodeAction = s.textDocumentCodeAction
 
 	s.server = glspserv.NewServer(&s.handler, LS_NAME, logLevel > 2)
 
@@ -57,9 +80,31 @@ func Run(logLevel int) {
 	s.server.RunStdio()
 }
 
+func newServer() *Server {
+	return &Server{
+		dialect:     token.DefaultDialect,
+		files:       map[string]*File{},
+		log:         commonlog.GetLogger(LS_NAME),
+		packagePath: defaultPackagePath,
+	}
+}
+
 func (s *Server) initialize(ctx *glsp.Context, params *protocol.InitializeParams) (any, error) {
 	capabilities := s.handler.CreateServerCapabilities()
-	s.rootPath = *params.RootPath
+	capabilities.CompletionProvider.TriggerCharacters = []string{".", ":"}
+	capabilities.RenameProvider = protocol.RenameOptions{PrepareProvider: util.Ptr(true)}
+	if params.RootURI != nil {
+		if path, err := uriToPath(*params.RootURI); err == nil {
+			s.rootPath = path
+		}
+	} else if params.RootPath != nil {
+		s.rootPath = *params.RootPath
+	}
+	if workspace := params.Capabilities.Workspace; workspace != nil && workspace.DidChangeWatchedFiles != nil {
+		s.watchFiles = workspace.DidChangeWatchedFiles.DynamicRegistration != nil && *workspace.DidChangeWatchedFiles.DynamicRegistration
+	}
+	s.applySettings(params.InitializationOptions)
+	s.loadConfigFile()
 
 	return protocol.InitializeResult{
 		Capabilities: capabilities,
@@ -68,13 +113,17 @@ func (s *Server) initialize(ctx *glsp.Context, params *protocol.InitializeParams
 }
 
 func (s *Server) initialized(ctx *glsp.Context, params *protocol.InitializedParams) error {
-	go func() {
-		s.isInitialized = true
-		s.log.Debug("Initialized")
-		for _, file := range s.files {
-			s.publishDiagnostics(ctx, file)
-		}
-	}()
+	s.indexWorkspace()
+	s.isInitialized = true
+	s.log.Debug("Initialized")
+	for _, uri := range s.sortedURIs() {
+		s.publishDiagnostics(ctx, s.files[uri])
+	}
+	if s.watchFiles {
+		// The registration waits for a response from the client, which can't
+		// be read until this notification is handled
+		go s.registerFileWatchers(ctx)
+	}
 	return nil
 }
 
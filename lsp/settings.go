@@ -0,0 +1,185 @@
+package lsp
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// The project configuration file in the workspace root. It contains the same
+// settings as the client, which take precedence over it.
+const configFileName = ".luarc.json"
+
+// Settings are provided by the client as initializationOptions or through
+// workspace/didChangeConfiguration, or by the project configuration file. They
+// may be nested under a "luapls" key.
+type Settings struct {
+	Dialect string `json:"dialect"`
+	// Allow strings in arithmetic without a warning
+	StringCoercion *bool `json:"stringCoercion"`
+	// Where require searches for modules, in the format of Lua's
+	// package.path. Relative paths are relative to the workspace root.
+	PackagePath string `json:"packagePath"`
+	// Directories of Lua files that can be required but are not checked,
+	// relative to the workspace root
+	Library []string `json:"library"`
+	// Global variables that are defined outside of the workspace
+	Globals []string `json:"globals"`
+	// The severity of diagnostics by their code, such as "undefined-global":
+	// "error", "warning", "information", "hint", or "off" to disable them
+	Diagnostics map[string]string `json:"diagnostics"`
+	// Glob patterns of workspace files that are not indexed. Patterns without
+	// a slash match any file or directory with that name.
+	Ignore []string `json:"ignore"`
+	// The path of the log file
+	LogPath string `json:"logPath"`
+}
+
+func (s *Server) workspaceDidChangeConfiguration(ctx *glsp.Context, params *protocol.DidChangeConfigurationParams) error {
+	changed := s.applySettings(params.Settings)
+	// The project configuration is also read again, in case the client
+	// doesn't watch it
+	if s.loadConfigFile() || changed {
+		s.reload(ctx)
+	}
+	return nil
+}
+
+// applySettings updates the server from the given client settings, and
+// returns true if open files need to be reparsed and checked.
+func (s *Server) applySettings(raw any) bool {
+	if raw == nil {
+		return false
+	}
+	bytes, err := json.Marshal(raw)
+	if err != nil {
+		s.log.Errorf("Invalid settings: %s", err)
+		return false
+	}
+	settings, err := parseSettings(bytes)
+	if err != nil {
+		s.log.Errorf("Invalid settings: %s", err)
+		return false
+	}
+	s.clientSettings = settings
+	return s.updateSettings()
+}
+
+// loadConfigFile reads the project configuration file, and returns true if
+// files need to be reparsed and checked.
+func (s *Server) loadConfigFile() bool {
+	if s.rootPath == "" {
+		return false
+	}
+	settings := Settings{}
+	bytes, err := os.ReadFile(filepath.Join(s.rootPath, configFileName))
+	if err == nil {
+		settings, err = parseSettings(bytes)
+		if err != nil {
+			s.log.Errorf("Invalid %s: %s", configFileName, err)
+			return false
+		}
+	} else if !os.IsNotExist(err) {
+		s.log.Errorf("Failed to read %s: %s", configFileName, err)
+	}
+	s.projectSettings = settings
+	return s.updateSettings()
+}
+
+// parseSettings decodes settings, which may be nested under a "luapls" key.
+func parseSettings(bytes []byte) (Settings, error) {
+	var wrapper struct {
+		Luapls *Settings `json:"luapls"`
+	}
+	if err := json.Unmarshal(bytes, &wrapper); err == nil && wrapper.Luapls != nil {
+		return *wrapper.Luapls, nil
+	}
+	var settings Settings
+	err := json.Unmarshal(bytes, &settings)
+	return settings, err
+}
+
+// updateSettings applies the client settings over the project settings, and
+// returns true if they changed.
+func (s *Server) updateSettings() bool {
+	settings := mergeSettings(s.projectSettings, s.clientSettings)
+	if reflect.DeepEqual(settings, s.settings) {
+		return false
+	}
+	if settings.LogPath != s.settings.LogPath && settings.LogPath != "" {
+		commonlog.Configure(s.logLevel, &settings.LogPath)
+	}
+	s.settings = settings
+
+	s.dialect = token.DefaultDialect
+	if settings.Dialect != "" {
+		dialect, ok := token.ParseDialect(settings.Dialect)
+		if ok {
+			s.dialect = dialect
+		} else {
+			s.log.Errorf("Unknown dialect '%s'", settings.Dialect)
+		}
+	}
+	s.options = types.Options{Globals: settings.Globals}
+	if settings.StringCoercion != nil {
+		s.options.StringCoercion = *settings.StringCoercion
+	}
+	s.packagePath = defaultPackagePath
+	if settings.PackagePath != "" {
+		s.packagePath = settings.PackagePath
+	}
+	return true
+}
+
+// mergeSettings returns the settings of base, overridden by those that are
+// set in other. Lists are combined.
+func mergeSettings(base, other Settings) Settings {
+	merged := base
+	if other.Dialect != "" {
+		merged.Dialect = other.Dialect
+	}
+	if other.StringCoercion != nil {
+		merged.StringCoercion = other.StringCoercion
+	}
+	if other.PackagePath != "" {
+		merged.PackagePath = other.PackagePath
+	}
+	merged.Library = append(append([]string{}, base.Library...), other.Library...)
+	merged.Globals = append(append([]string{}, base.Globals...), other.Globals...)
+	merged.Ignore = append(append([]string{}, base.Ignore...), other.Ignore...)
+	merged.Diagnostics = maps.Clone(base.Diagnostics)
+	if merged.Diagnostics == nil {
+		merged.Diagnostics = map[string]string{}
+	}
+	maps.Copy(merged.Diagnostics, other.Diagnostics)
+	if other.LogPath != "" {
+		merged.LogPath = other.LogPath
+	}
+	return merged
+}
+
+// diagnosticSeverity returns the configured severity of diagnostics with the
+// given code, or nil if they are disabled.
+func (s *Server) diagnosticSeverity(code string, severity protocol.DiagnosticSeverity) *protocol.DiagnosticSeverity {
+	switch s.settings.Diagnostics[code] {
+	case "error":
+		severity = protocol.DiagnosticSeverityError
+	case "warning":
+		severity = protocol.DiagnosticSeverityWarning
+	case "information":
+		severity = protocol.DiagnosticSeverityInformation
+	case "hint":
+		severity = protocol.DiagnosticSeverityHint
+	case "off":
+		return nil
+	}
+	return &severity
+}
@@ -0,0 +1,357 @@
+package lsp
+
+import (
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/types"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// The default search path of require, in the format of Lua's package.path.
+const defaultPackagePath = "?.lua;?/init.lua"
+
+// indexWorkspace loads every Lua file in the workspace and its libraries, so
+// that open files can require them.
+func (s *Server) indexWorkspace() {
+	if s.rootPath == "" {
+		return
+	}
+	timer := time.Now()
+	s.indexDir(s.rootPath)
+	for _, dir := range s.libraryDirs() {
+		s.indexDir(dir)
+	}
+	s.checkFiles()
+	s.log.Debugf("Indexed %d files in %s", len(s.files), time.Since(timer).String())
+}
+
+// indexDir loads the Lua files in dir and its subdirectories, except for
+// hidden and ignored ones.
+func (s *Server) indexDir(dir string) {
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") || s.isIgnored(path) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() && filepath.Ext(path) == ".lua" {
+			s.loadFile(path)
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Errorf("Failed to index '%s': %s", dir, err)
+	}
+}
+
+// isIgnored returns whether path matches one of the ignore patterns. Patterns
+// with a slash are matched against the path relative to the workspace root,
+// and other patterns against its last element.
+func (s *Server) isIgnored(path string) bool {
+	rel, err := filepath.Rel(s.rootPath, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range s.settings.Ignore {
+		pattern = strings.TrimSuffix(pattern, "/")
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = filepath.Base(path)
+		}
+		if matched, _ := pathpkg.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isIndexed returns whether path is a file of the workspace or of a library.
+func (s *Server) isIndexed(path string) bool {
+	if s.isLibrary(path) {
+		return true
+	}
+	rel, err := filepath.Rel(s.rootPath, path)
+	return s.rootPath != "" && err == nil && !strings.HasPrefix(rel, "..") && !s.isIgnored(path)
+}
+
+// libraryDirs returns the absolute paths of the library directories.
+func (s *Server) libraryDirs() []string {
+	dirs := []string{}
+	for _, dir := range s.settings.Library {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.rootPath, dir)
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	return dirs
+}
+
+// isLibrary returns whether path is in a library directory.
+func (s *Server) isLibrary(path string) bool {
+	for _, dir := range s.libraryDirs() {
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// reload indexes the workspace again and checks every file, after the
+// settings changed.
+func (s *Server) reload(ctx *glsp.Context) {
+	previous := s.sortedURIs()
+	for uri, file := range s.files {
+		if !file.open {
+			delete(s.files, uri)
+			continue
+		}
+		// The dialect may have changed
+		parserFile := parser.NewWithDialect(file.Src, s.dialect).ParseFile()
+		file.File = &parserFile
+		file.checked = false
+	}
+	s.indexWorkspace()
+	s.checkFiles()
+	for _, uri := range previous {
+		if s.files[uri] == nil {
+			// Clear the diagnostics of files that are now ignored
+			ctx.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+				URI:         uri,
+				Diagnostics: []protocol.Diagnostic{},
+			})
+		}
+	}
+	for _, uri := range s.sortedURIs() {
+		s.publishDiagnostics(ctx, s.files[uri])
+	}
+}
+
+// registerFileWatchers asks the client to report changes to the project
+// configuration and to Lua files that are not open.
+func (s *Server) registerFileWatchers(ctx *glsp.Context) {
+	ctx.Call(protocol.ServerClientRegisterCapability, protocol.RegistrationParams{
+		Registrations: []protocol.Registration{{
+			ID:     "luapls-watched-files",
+			Method: string(protocol.MethodWorkspaceDidChangeWatchedFiles),
+			RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{
+				Watchers: []protocol.FileSystemWatcher{
+					{GlobPattern: "**/" + configFileName},
+					{GlobPattern: "**/*.lua"},
+				},
+			},
+		}},
+	}, nil)
+}
+
+func (s *Server) workspaceDidChangeWatchedFiles(ctx *glsp.Context, params *protocol.DidChangeWatchedFilesParams) error {
+	for _, change := range params.Changes {
+		path, err := uriToPath(change.URI)
+		if err != nil {
+			s.log.Errorf("%s", err)
+			continue
+		}
+		if path == filepath.Join(s.rootPath, configFileName) {
+			if s.loadConfigFile() {
+				s.reload(ctx)
+			}
+			continue
+		}
+		file := s.files[change.URI]
+		if filepath.Ext(path) != ".lua" || (file != nil && file.open) {
+			// The client owns the contents of open files
+			continue
+		}
+		delete(s.files, change.URI)
+		if change.Type != protocol.FileChangeTypeDeleted && s.isIndexed(path) {
+			if loaded := s.loadFile(path); loaded != nil {
+				file = loaded
+				s.checkFile(file)
+				s.publishDiagnostics(ctx, file)
+			}
+		}
+		if file != nil {
+			s.checkDependents(ctx, file)
+		}
+	}
+	return nil
+}
+
+// loadFile reads and parses the file at path if it is not loaded yet. It is
+// type checked when it is first required, or by checkFiles.
+func (s *Server) loadFile(path string) *File {
+	uri, err := pathToURI(path)
+	if err != nil {
+		s.log.Errorf("%s", err)
+		return nil
+	}
+	if file := s.files[uri]; file != nil {
+		return file
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	file := &File{Path: uri, Src: string(src), library: s.isLibrary(path)}
+	parserFile := parser.NewWithDialect(file.Src, s.dialect).ParseFile()
+	file.File = &parserFile
+	// The scopes tell which globals the file assigns before it is checked
+	file.Env = types.NewEnvironment(file.File)
+	s.files[uri] = file
+	return file
+}
+
+// checkFiles type checks every file that is not checked yet.
+func (s *Server) checkFiles() {
+	for _, uri := range s.sortedURIs() {
+		if file := s.files[uri]; !file.checked {
+			s.checkFile(file)
+		}
+	}
+}
+
+// require returns the value of the module with the given name, as loaded by
+// file. The module is type checked first if needed.
+func (s *Server) require(file *File, name string) types.Type {
+	module := s.findModule(name)
+	if module == nil {
+		return nil
+	}
+	file.dependencies[module.Path] = true
+	if module.checking {
+		// The modules require each other
+		return &types.Unknown{}
+	}
+	if !module.checked {
+		s.checkFile(module)
+	}
+	return module.Env.Module
+}
+
+// globalType returns the type of a global variable that another file in the
+// workspace assigns, or nil if there is none. That file is type checked first
+// if needed.
+func (s *Server) globalType(file *File, name string) types.Type {
+	for _, uri := range s.sortedURIs() {
+		other := s.files[uri]
+		if other == file {
+			continue
+		}
+		// Only the file that assigns the global is checked, so that checking
+		// unrelated files can't find this one in the middle of its check
+		if len(other.Env.Scopes.GlobalWrites[name]) == 0 {
+			continue
+		}
+		file.dependencies[uri] = true
+		if !other.checked && !other.checking {
+			s.checkFile(other)
+		}
+		if typ := other.Env.GlobalTypes[name]; typ != nil && !other.checking {
+			return typ
+		}
+		// The files use each other's globals
+		return &types.Unknown{}
+	}
+	return nil
+}
+
+// findModule searches the package path for the file of the module with the
+// given name. Relative paths are relative to the workspace root, and then to
+// each library directory.
+func (s *Server) findModule(name string) *File {
+	modulePath := strings.ReplaceAll(name, ".", string(filepath.Separator))
+	for _, dir := range append([]string{s.rootPath}, s.libraryDirs()...) {
+		for _, template := range strings.Split(s.packagePath, ";") {
+			if template == "" {
+				continue
+			}
+			path := strings.ReplaceAll(template, "?", modulePath)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if file := s.loadFile(path); file != nil {
+				return file
+			}
+		}
+	}
+	return nil
+}
+
+// checkDependents type checks the files that require the given file, directly
+// or indirectly, after it changed.
+func (s *Server) checkDependents(ctx *glsp.Context, file *File) {
+	dependents := s.dependents(file.Path)
+	for _, dependent := range dependents {
+		dependent.checked = false
+	}
+	for _, dependent := range dependents {
+		// Checking a dependent checks the dependents that it requires first
+		if !dependent.checked {
+			s.checkFile(dependent)
+		}
+		s.publishDiagnostics(ctx, dependent)
+	}
+}
+
+// dependents returns the files that require the file with the given URI or
+// use the globals that it assigns, directly or indirectly.
+func (s *Server) dependents(uri string) []*File {
+	found := map[string]bool{uri: true}
+	dependents := []*File{}
+	queue := []string{uri}
+	// Files that didn't find a global before it was assigned don't depend on
+	// the file yet
+	if file := s.files[uri]; file != nil {
+		for _, other := range s.sortedURIs() {
+			if found[other] {
+				continue
+			}
+			for name := range file.Env.Scopes.GlobalWrites {
+				if len(s.files[other].Env.Globals[name]) > 0 {
+					found[other] = true
+					dependents = append(dependents, s.files[other])
+					queue = append(queue, other)
+					break
+				}
+			}
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, other := range s.sortedURIs() {
+			file := s.files[other]
+			if file.dependencies[current] && !found[other] {
+				found[other] = true
+				dependents = append(dependents, file)
+				queue = append(queue, other)
+			}
+		}
+	}
+	return dependents
+}
+
+// sortedURIs returns the URIs of the loaded files in a stable order.
+func (s *Server) sortedURIs() []string {
+	uris := make([]string, 0, len(s.files))
+	for uri := range s.files {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
@@ -0,0 +1,200 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// newTestWorkspace returns a server whose workspace contains the given files.
+func newTestWorkspace(t *testing.T, files map[string]string) *Server {
+	root := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+	}
+	s := newServer()
+	s.rootPath = root
+	s.loadConfigFile()
+	s.indexWorkspace()
+	return s
+}
+
+func (s *Server) testFile(t *testing.T, name string) *File {
+	uri, err := pathToURI(filepath.Join(s.rootPath, name))
+	require.NoError(t, err)
+	file := s.files[uri]
+	require.NotNil(t, file)
+	return file
+}
+
+func TestWorkspace(t *testing.T) {
+	s := newTestWorkspace(t, map[string]string{
+		"main.lua": `local util = require("lib.util")
+local config = require("config")
+print(util.add(1, 2), config.name)
+`,
+		"lib/util.lua": `local M = {}
+function M.add(a, b)
+  return a + b
+end
+return M
+`,
+		"config/init.lua": `return { name = "luapls" }`,
+		".git/hooks.lua":  `error()`,
+	})
+	assert.Len(t, s.files, 3)
+
+	main := s.testFile(t, "main.lua")
+	util := s.testFile(t, "lib/util.lua")
+	assert.Empty(t, main.Env.Errors)
+	assert.Equal(t, util.Env.Module, main.Env.Types[ast.GetNode(&main.File.Block, 6).Node])
+	assert.Equal(t, []*File{main}, s.dependents(util.Path))
+
+	// Completion of the fields of a module
+	items := getCompletions(main, strings.Index(main.Src, "add"), s.dialect)
+	assert.Equal(t, []string{"add"}, completionLabels(items))
+
+	// Definitions in other files
+	location := s.findModuleDefinition(main, ast.GetNode(&main.File.Block, strings.Index(main.Src, "add")))
+	require.NotNil(t, location)
+	assert.Equal(t, util.Path, location.URI)
+	assert.Equal(t, protocol.Position{Line: 1, Character: 9}, location.Range.Start)
+	location = s.findModuleDefinition(main, ast.GetNode(&main.File.Block, strings.Index(main.Src, "config\"")))
+	require.NotNil(t, location)
+	assert.Equal(t, s.testFile(t, "config/init.lua").Path, location.URI)
+
+	// Dependents are checked again when a module changes
+	ctx := &glsp.Context{Notify: func(method string, params any) {}}
+	util.Src = "return {}"
+	s.parseFile(util)
+	s.checkDependents(ctx, util)
+	require.Len(t, main.Env.Errors, 1)
+	assert.Equal(t, "Unknown field 'add'", main.Env.Errors[0].Message)
+}
+
+func TestRequireCycle(t *testing.T) {
+	s := newTestWorkspace(t, map[string]string{
+		"a.lua": `local b = require("b") return { b = b }`,
+		"b.lua": `local a = require("a") return { a = a }`,
+	})
+	assert.Empty(t, s.testFile(t, "a.lua").Env.Errors)
+	assert.Equal(t, "{a: unknown}", s.testFile(t, "b.lua").Env.Module.String())
+	assert.Equal(t, "{b: {a: unknown}}", s.testFile(t, "a.lua").Env.Module.String())
+}
+
+func TestConfigFile(t *testing.T) {
+	s := newTestWorkspace(t, map[string]string{
+		".luarc.json": `{
+  "dialect": "Lua 5.1",
+  "globals": ["game"],
+  "library": ["../lib"],
+  "ignore": ["build", "test/*.lua"],
+  "diagnostics": { "type": "off", "syntax": "hint" }
+}`,
+		"main.lua":          `local goto = require("shared") print(game, goto.value)`,
+		"build/out.lua":     ``,
+		"test/spec.lua":     ``,
+		"test/nested/a.lua": ``,
+		"../lib/shared.lua": `return { value = 1 }`,
+	})
+	assert.Equal(t, token.Lua51, s.dialect)
+	assert.Equal(t, []string{"game"}, s.options.Globals)
+
+	main := s.testFile(t, "main.lua")
+	assert.Empty(t, main.File.Errors)
+	assert.Empty(t, main.Env.Errors)
+	assert.Nil(t, s.files[must(pathToURI(filepath.Join(s.rootPath, "build/out.lua")))])
+	assert.Nil(t, s.files[must(pathToURI(filepath.Join(s.rootPath, "test/spec.lua")))])
+	assert.NotNil(t, s.files[must(pathToURI(filepath.Join(s.rootPath, "test/nested/a.lua")))])
+	shared := s.testFile(t, "../lib/shared.lua")
+	assert.True(t, shared.library)
+
+	assert.Nil(t, s.diagnosticSeverity("type", protocol.DiagnosticSeverityWarning))
+	assert.Equal(t, protocol.DiagnosticSeverityHint, *s.diagnosticSeverity("syntax", protocol.DiagnosticSeverityError))
+
+	// Client settings take precedence
+	assert.True(t, s.applySettings(map[string]any{"luapls": map[string]any{"dialect": "5.4", "globals": []string{"script"}}}))
+	assert.Equal(t, token.Lua54, s.dialect)
+	assert.Equal(t, []string{"game", "script"}, s.options.Globals)
+	assert.False(t, s.applySettings(map[string]any{"dialect": "5.4", "globals": []string{"script"}}))
+
+	// Changes to the file are picked up
+	notified := []string{}
+	ctx := &glsp.Context{Notify: func(method string, params any) {
+		notified = append(notified, params.(protocol.PublishDiagnosticsParams).URI)
+	}}
+	require.NoError(t, os.WriteFile(filepath.Join(s.rootPath, configFileName), []byte(`{"ignore": ["main.lua"]}`), 0o644))
+	require.NoError(t, s.workspaceDidChangeWatchedFiles(ctx, &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: must(pathToURI(filepath.Join(s.rootPath, configFileName))), Type: protocol.FileChangeTypeChanged}},
+	}))
+	assert.Nil(t, s.files[main.Path])
+	assert.Contains(t, notified, main.Path)
+	assert.Equal(t, []string{"script"}, s.options.Globals)
+}
+
+func must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+func TestWorkspaceGlobals(t *testing.T) {
+	s := newTestWorkspace(t, map[string]string{
+		"main.lua":  `print(settings.name, helper, missing)`,
+		"setup.lua": `settings = { name = "luapls" }`,
+	})
+	main := s.testFile(t, "main.lua")
+	setup := s.testFile(t, "setup.lua")
+	require.Len(t, main.Env.Errors, 2)
+	assert.Equal(t, "Undefined global 'helper'", main.Env.Errors[0].Message)
+	assert.Equal(t, "string", main.Env.Types[ast.GetNode(&main.File.Block, strings.Index(main.Src, "name")).Node].String())
+	assert.Equal(t, []*File{main}, s.dependents(setup.Path))
+
+	// Files that use a global are checked again when it is assigned
+	ctx := &glsp.Context{Notify: func(method string, params any) {}}
+	setup.Src = "settings = {}\nfunction helper() end"
+	s.parseFile(setup)
+	s.checkDependents(ctx, setup)
+	require.Len(t, main.Env.Errors, 2)
+	assert.Equal(t, "Unknown field 'name'", main.Env.Errors[0].Message)
+	assert.Equal(t, "Undefined global 'missing'", main.Env.Errors[1].Message)
+}
+
+func TestGlobalsOfRequiredModule(t *testing.T) {
+	// Checking a.lua assigns a global before b.lua is checked
+	s := newTestWorkspace(t, map[string]string{
+		"a.lua": `local M = { x = 1 } config = { name = "a" } return M`,
+		"b.lua": `local m = require("a") print(m.x, config.name)`,
+	})
+	b := s.testFile(t, "b.lua")
+	assert.Empty(t, b.Env.Errors)
+	assert.Equal(t, "{x: number}", b.Env.Types[ast.GetNode(&b.File.Block, strings.Index(b.Src, "m =")).Node].String())
+	assert.Equal(t, []*File{b}, s.dependents(s.testFile(t, "a.lua").Path))
+}
+
+func TestImportsAreCopied(t *testing.T) {
+	s := newTestWorkspace(t, map[string]string{
+		"a.lua": `local M = {} M.x = 1 config = { name = "a" } return M`,
+		"b.lua": `local m = require("a") m.injected = "s" config.debug = true`,
+		"c.lua": `local m = require("a") print(m.injected, config.debug)`,
+	})
+	assert.Equal(t, "{x: number}", s.testFile(t, "a.lua").Env.Module.String())
+	assert.Equal(t, "{name: string}", s.testFile(t, "a.lua").Env.GlobalTypes["config"].String())
+	b := s.testFile(t, "b.lua")
+	assert.Empty(t, b.Env.Errors)
+	c := s.testFile(t, "c.lua")
+	require.Len(t, c.Env.Errors, 2)
+	assert.Equal(t, "Unknown field 'injected'", c.Env.Errors[0].Message)
+	assert.Equal(t, "Unknown field 'debug'", c.Env.Errors[1].Message)
+}
@@ -7,10 +7,39 @@ import (
 )
 
 type Error struct {
-	Message string
-	Range   token.Range
+	Message  string
+	Range    token.Range
+	Code     string // Identifies the kind of error, such as "undefined-global"
+	Severity Severity
+	Related  []RelatedInfo `json:",omitempty"`
+	Tags     []Tag         `json:",omitempty"`
 }
 
 func (pe *Error) String() string {
 	return fmt.Sprintf("%s: %s", &pe.Range, pe.Message)
 }
+
+// Severity values match those of the language server protocol.
+type Severity int
+
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+// RelatedInfo points to another location in the same file that is relevant to
+// an error, such as the declaration of a variable.
+type RelatedInfo struct {
+	Message string
+	Range   token.Range
+}
+
+// Tag values match those of the language server protocol.
+type Tag int
+
+const (
+	TagUnnecessary Tag = iota + 1
+	TagDeprecated
+)
@@ -227,6 +227,19 @@ func (node *Invalid) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (node *LocalAttribute) MarshalJSON() ([]byte, error) {
+	type Alias LocalAttribute
+	return json.Marshal(&struct {
+		Type  string
+		Range token.Range
+		*Alias
+	}{
+		Type:  "LocalAttribute",
+		Range: Range(node),
+		Alias: (*Alias)(node),
+	})
+}
+
 func (node *LocalStatement) MarshalJSON() ([]byte, error) {
 	type Alias LocalStatement
 	return json.Marshal(&struct {
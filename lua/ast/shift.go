@@ -0,0 +1,77 @@
+package ast
+
+import (
+	"reflect"
+
+	"github.com/raiguard/luapls/lua/token"
+)
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// positionFields are the names of struct fields that hold a token.Pos. Since
+// token.Pos is an alias, they cannot be distinguished by type alone.
+var positionFields = map[string]bool{
+	"Pos":      true,
+	"Position": true,
+	"StartPos": true,
+}
+
+// Shift moves every position in the given node and its children, including
+// trivia, by delta. The node is modified in place.
+func Shift(node Node, delta int) {
+	if delta == 0 {
+		return
+	}
+	s := shifter{delta: delta, visited: map[uintptr]bool{}, shifted: map[uintptr]bool{}}
+	s.shift(reflect.ValueOf(node))
+}
+
+type shifter struct {
+	delta   int
+	visited map[uintptr]bool
+	// Units are copied by value, so their trivia slices may be reachable from
+	// more than one place. Each position must only be shifted once.
+	shifted map[uintptr]bool
+}
+
+func (s *shifter) shiftPos(pos reflect.Value) {
+	if pos.CanAddr() {
+		if s.shifted[pos.UnsafeAddr()] {
+			return
+		}
+		s.shifted[pos.UnsafeAddr()] = true
+	}
+	pos.SetInt(pos.Int() + int64(s.delta))
+}
+
+func (s *shifter) shift(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || s.visited[v.Pointer()] {
+			return
+		}
+		s.visited[v.Pointer()] = true
+		s.shift(v.Elem())
+	case reflect.Interface:
+		if !v.IsNil() {
+			s.shift(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			s.shift(v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type() == tokenType {
+			s.shiftPos(v.FieldByName("Pos"))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.Kind() == reflect.Int && positionFields[v.Type().Field(i).Name] {
+				s.shiftPos(field)
+				continue
+			}
+			s.shift(field)
+		}
+	}
+}
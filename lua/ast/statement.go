@@ -52,8 +52,8 @@ func (ds *DoStatement) Pos() token.Pos {
 func (ds *DoStatement) End() token.Pos {
 	return ds.EndTok.End()
 }
-func (bs *DoStatement) Leaves() (n []Node) {
-	return n
+func (ds *DoStatement) Leaves() []Node {
+	return []Node{&ds.Body}
 }
 
 type ForStatement struct {
@@ -75,8 +75,8 @@ func (fs *ForStatement) Pos() token.Pos {
 func (fs *ForStatement) End() token.Pos {
 	return fs.EndTok.End()
 }
-func (bs *ForStatement) Leaves() (n []Node) {
-	return n
+func (fs *ForStatement) Leaves() []Node {
+	return []Node{fs.Name, &fs.Start, &fs.Finish, fs.Step, &fs.Body}
 }
 
 type ForInStatement struct {
@@ -118,7 +118,7 @@ func (fs *FunctionStatement) Pos() token.Pos {
 	if fs.LocalTok != nil {
 		return fs.LocalTok.Pos()
 	}
-	return fs.Name.Pos()
+	return fs.FuncTok.Pos()
 }
 func (fs *FunctionStatement) End() token.Pos {
 	return fs.EndTok.End()
@@ -154,7 +154,7 @@ func (is *IfStatement) Pos() token.Pos {
 	return is.IfTok.Pos()
 }
 func (is *IfStatement) End() token.Pos {
-	return is.EndTok.Pos()
+	return is.EndTok.End()
 }
 func (is *IfStatement) Leaves() (n []Node) {
 	for _, i := range is.Clauses {
@@ -202,6 +202,7 @@ func (ls *LabelStatement) leaf() {}
 type LocalStatement struct {
 	LocalTok  Unit
 	Names     Punctuated[*Identifier]
+	Attribs   []*LocalAttribute `json:",omitempty"` // Parallel to Names, nil if there are no attributes
 	AssignTok *Unit
 	Exps      *Punctuated[Expression]
 }
@@ -217,12 +218,32 @@ func (ls *LocalStatement) End() token.Pos {
 	if ls.AssignTok != nil {
 		return ls.AssignTok.End()
 	}
+	if len(ls.Attribs) > 0 && ls.Attribs[len(ls.Attribs)-1] != nil {
+		return max(ls.Names.End(), ls.Attribs[len(ls.Attribs)-1].End())
+	}
 	return ls.Names.End()
 }
 func (ls *LocalStatement) Leaves() []Node {
 	return []Node{&ls.Names, ls.Exps}
 }
 
+// LocalAttribute is a variable attribute such as `<const>` or `<close>`.
+type LocalAttribute struct {
+	LeftAngle  Unit
+	Name       *Identifier
+	RightAngle Unit
+}
+
+func (la *LocalAttribute) Pos() token.Pos {
+	return la.LeftAngle.Pos()
+}
+func (la *LocalAttribute) End() token.Pos {
+	return la.RightAngle.End()
+}
+func (la *LocalAttribute) Leaves() (n []Node) {
+	return
+}
+
 type RepeatStatement struct {
 	RepeatTok Unit
 	Body      Block
@@ -70,6 +70,10 @@ func (node *Invalid) String() string {
 	return fmt.Sprintf("%s()@%v", "Invalid", node.Pos())
 }
 
+func (node *LocalAttribute) String() string {
+	return fmt.Sprintf("%s(%s)@%v", "LocalAttribute", node.Name.Token.Literal, node.Pos())
+}
+
 func (node *LocalStatement) String() string {
 	return fmt.Sprintf("%s()@%v", "LocalStatement", node.Pos())
 }
@@ -18,8 +18,8 @@ func (taf *TableArrayField) Pos() token.Pos {
 func (taf *TableArrayField) End() token.Pos {
 	return taf.Expr.End()
 }
-func (taf *TableArrayField) Leaves() (n []Node) {
-	return
+func (taf *TableArrayField) Leaves() []Node {
+	return []Node{taf.Expr}
 }
 
 type TableSimpleKeyField struct {
@@ -35,8 +35,8 @@ func (tf *TableSimpleKeyField) Pos() token.Pos {
 func (tf *TableSimpleKeyField) End() token.Pos {
 	return tf.Expr.End()
 }
-func (tf *TableSimpleKeyField) Leaves() (n []Node) {
-	return
+func (tf *TableSimpleKeyField) Leaves() []Node {
+	return []Node{&tf.Name, tf.Expr}
 }
 
 type TableExpressionKeyField struct {
@@ -54,6 +54,6 @@ func (tf *TableExpressionKeyField) Pos() token.Pos {
 func (tf *TableExpressionKeyField) End() token.Pos {
 	return tf.Expr.End()
 }
-func (tf *TableExpressionKeyField) Leaves() (n []Node) {
-	return
+func (tf *TableExpressionKeyField) Leaves() []Node {
+	return []Node{tf.Name, tf.Expr}
 }
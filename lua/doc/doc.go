@@ -0,0 +1,239 @@
+// Package doc parses LuaCATS (EmmyLua) annotations in `---` doc comments.
+package doc
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// Comment is a block of consecutive `---` lines.
+type Comment struct {
+	span
+	Description string
+	Annotations []Annotation
+	Errors      []ast.Error
+}
+
+// Node is an annotation or a type expression.
+type Node interface {
+	Pos() token.Pos
+	End() token.Pos
+}
+
+type span struct {
+	Range token.Range
+}
+
+func (s *span) Pos() token.Pos {
+	return s.Range.Start
+}
+
+func (s *span) End() token.Pos {
+	return s.Range.End
+}
+
+// Name is an identifier in an annotation.
+type Name struct {
+	span
+	Name string
+}
+
+type Annotation interface {
+	Node
+	annotationNode()
+}
+
+type (
+	// `---@alias Name Type`, optionally followed by `---| Type` lines
+	Alias struct {
+		span
+		Name *Name
+		Type Type
+	}
+	// `---@cast name [+|-]Type`
+	Cast struct {
+		span
+		Name *Name
+		Op   string // "+", "-" or ""
+		Type Type
+	}
+	// `---@class [(exact)] Name[<T, ...>][: Parent, ...]`
+	Class struct {
+		span
+		Exact   bool
+		Name    *Name
+		Params  []*GenericParam
+		Parents []Type
+	}
+	// `---@deprecated [description]`
+	Deprecated struct {
+		span
+		Description string
+	}
+	// `---@diagnostic action[: code, ...]`
+	Diagnostic struct {
+		span
+		Action string // disable, enable, disable-line or disable-next-line
+		Codes  []*Name
+	}
+	// `---@field [visibility] name[?] Type [description]`. Exactly one of
+	// Name and Key is set.
+	Field struct {
+		span
+		Visibility  string
+		Name        *Name
+		Key         Type // `[Type]`
+		Optional    bool
+		Type        Type
+		Description string
+	}
+	// `---@generic T[: Constraint], ...`
+	Generic struct {
+		span
+		Params []*GenericParam
+	}
+	// `---@overload fun(...)`
+	Overload struct {
+		span
+		Type *FunctionType
+	}
+	// `---@param name[?] Type [description]`
+	Param struct {
+		span
+		Name        *Name
+		Optional    bool
+		Type        Type
+		Description string
+	}
+	// `---@return Type [name], ... [description]`
+	Return struct {
+		span
+		Values      []*ReturnValue
+		Description string
+	}
+	// `---@type Type, ...`
+	TypeAnnotation struct {
+		span
+		Types []Type
+	}
+)
+
+func (a *Alias) annotationNode()          {}
+func (c *Cast) annotationNode()           {}
+func (c *Class) annotationNode()          {}
+func (d *Deprecated) annotationNode()     {}
+func (d *Diagnostic) annotationNode()     {}
+func (f *Field) annotationNode()          {}
+func (g *Generic) annotationNode()        {}
+func (o *Overload) annotationNode()       {}
+func (p *Param) annotationNode()          {}
+func (r *Return) annotationNode()         {}
+func (t *TypeAnnotation) annotationNode() {}
+
+type GenericParam struct {
+	span
+	Name       *Name
+	Constraint Type // Optional
+}
+
+type ReturnValue struct {
+	span
+	Type Type
+	Name *Name // Optional
+}
+
+// Type is a type expression.
+type Type interface {
+	Node
+	typeNode()
+}
+
+type (
+	// `Type[]`
+	ArrayType struct {
+		span
+		Elem Type
+	}
+	// `fun(name: Type, ...): Type, ...`
+	FunctionType struct {
+		span
+		Params  []*FunctionParam
+		Returns []Type
+	}
+	// `Name<Type, ...>`, such as `table<string, number>`
+	GenericType struct {
+		span
+		Name *Name
+		Args []Type
+	}
+	// A string, number or boolean literal
+	LiteralType struct {
+		span
+		Value string
+	}
+	// A named type, such as `number` or `Foo.Bar`
+	NamedType struct {
+		span
+		Name string
+	}
+	// `Type?`
+	OptionalType struct {
+		span
+		Inner Type
+	}
+	// `{ name: Type, [Type]: Type }`
+	TableType struct {
+		span
+		Fields []*TableTypeField
+	}
+	// `Type | Type`
+	UnionType struct {
+		span
+		Types []Type
+	}
+	// `...`, `...: Type` or `...Type`
+	VarargType struct {
+		span
+		Type Type // Optional
+	}
+)
+
+func (a *ArrayType) typeNode()    {}
+func (f *FunctionType) typeNode() {}
+func (g *GenericType) typeNode()  {}
+func (l *LiteralType) typeNode()  {}
+func (n *NamedType) typeNode()    {}
+func (o *OptionalType) typeNode() {}
+func (t *TableType) typeNode()    {}
+func (u *UnionType) typeNode()    {}
+func (v *VarargType) typeNode()   {}
+
+type FunctionParam struct {
+	span
+	Name     *Name
+	Optional bool
+	Type     Type // Optional
+}
+
+// TableTypeField has exactly one of Name and Key set.
+type TableTypeField struct {
+	span
+	Name     *Name
+	Key      Type
+	Optional bool
+	Type     Type
+}
+
+// Find returns the annotations of the given type in the comment.
+func Find[T Annotation](c *Comment) []T {
+	if c == nil {
+		return nil
+	}
+	var found []T
+	for _, annotation := range c.Annotations {
+		if a, ok := annotation.(T); ok {
+			found = append(found, a)
+		}
+	}
+	return found
+}
@@ -0,0 +1,700 @@
+package doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// FromTrivia parses the doc comment at the end of the given leading trivia,
+// which is the doc comment of the statement that follows it. A blank line or
+// an ordinary comment ends the block. Returns nil if there is no doc comment.
+func FromTrivia(trivia []token.Token) *Comment {
+	start := len(trivia)
+	for i := len(trivia) - 1; i >= 0; i-- {
+		tok := trivia[i]
+		if tok.Type == token.WHITESPACE {
+			if strings.Count(tok.Literal, "\n") > 1 {
+				break
+			}
+			continue
+		}
+		if !isDocComment(tok) {
+			break
+		}
+		start = i
+	}
+	comments := []token.Token{}
+	for _, tok := range trivia[start:] {
+		if tok.Type == token.COMMENT {
+			comments = append(comments, tok)
+		}
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+	return Parse(comments)
+}
+
+func isDocComment(tok token.Token) bool {
+	return tok.Type == token.COMMENT && strings.HasPrefix(tok.Literal, "---") && !strings.HasPrefix(tok.Literal, "----")
+}
+
+// Parse parses a block of `---` comments. Comments that are not doc comments
+// are ignored.
+func Parse(comments []token.Token) *Comment {
+	c := &Comment{Errors: []ast.Error{}}
+	descriptions := []string{}
+	for i, tok := range comments {
+		if i == 0 {
+			c.Range.Start = tok.Pos
+		}
+		c.Range.End = tok.End()
+		if !isDocComment(tok) {
+			continue
+		}
+		content := strings.TrimRight(tok.Literal[3:], "\r\n")
+		p := &parser{src: content, base: tok.Pos + 3, comment: c}
+		p.skipSpace()
+		switch {
+		case p.peekByte() == '@':
+			if annotation := p.parseAnnotation(); annotation != nil {
+				c.Annotations = append(c.Annotations, annotation)
+			}
+		case p.peekByte() == '|':
+			p.parseAliasContinuation()
+		default:
+			descriptions = append(descriptions, strings.TrimSpace(content))
+		}
+	}
+	c.Description = strings.TrimSpace(strings.Join(descriptions, "\n"))
+	return c
+}
+
+type parser struct {
+	src     string
+	base    token.Pos // The position of src in the file
+	pos     int
+	comment *Comment
+}
+
+func (p *parser) errorf(start, end int, format string, args ...any) {
+	p.comment.Errors = append(p.comment.Errors, ast.Error{
+		Message:  fmt.Sprintf(format, args...),
+		Range:    token.Range{Start: p.base + start, End: p.base + max(end, start+1)},
+		Code:     "doc-syntax",
+		Severity: ast.SeverityWarning,
+	})
+}
+
+func (p *parser) rng(start int) token.Range {
+	return token.Range{Start: p.base + start, End: p.base + p.pos}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) peekByte() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) atEnd() bool {
+	p.skipSpace()
+	return p.pos >= len(p.src)
+}
+
+// accept consumes s if it is next, after any whitespace.
+func (p *parser) accept(s string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) bool {
+	if p.accept(s) {
+		return true
+	}
+	p.errorf(p.pos, p.wordEnd(), "Expected '%s'", s)
+	return false
+}
+
+// wordEnd returns the end of the word at the current position, for errors.
+func (p *parser) wordEnd() int {
+	end := p.pos
+	for end < len(p.src) && p.src[end] != ' ' && p.src[end] != '\t' {
+		end++
+	}
+	return end
+}
+
+func isNameByte(b byte, first bool) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || b >= 0x80 ||
+		(!first && (('0' <= b && b <= '9') || b == '.' || b == '-'))
+}
+
+// parseName parses a name, which may contain dots.
+func (p *parser) parseName(what string) *Name {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && isNameByte(p.src[p.pos], p.pos == start) {
+		p.pos++
+	}
+	if p.pos == start {
+		p.errorf(start, p.wordEnd(), "Expected %s", what)
+		return nil
+	}
+	return &Name{span: span{p.rng(start)}, Name: p.src[start:p.pos]}
+}
+
+// rest returns the rest of the line as a description. A leading `#` or `@`
+// separator is removed.
+func (p *parser) rest() string {
+	p.skipSpace()
+	desc := strings.TrimSpace(p.src[p.pos:])
+	p.pos = len(p.src)
+	desc = strings.TrimPrefix(desc, "#")
+	return strings.TrimSpace(desc)
+}
+
+// unsupportedTags are the LuaCATS annotations that are valid, but have no
+// effect yet.
+var unsupportedTags = map[string]bool{
+	"as":        true,
+	"async":     true,
+	"enum":      true,
+	"meta":      true,
+	"module":    true,
+	"nodiscard": true,
+	"operator":  true,
+	"package":   true,
+	"private":   true,
+	"protected": true,
+	"see":       true,
+	"source":    true,
+	"vararg":    true,
+	"version":   true,
+}
+
+func (p *parser) parseAnnotation() Annotation {
+	start := p.pos
+	p.pos++ // @
+	tagStart := p.pos
+	for p.pos < len(p.src) && (isNameByte(p.src[p.pos], false)) {
+		p.pos++
+	}
+	tag := p.src[tagStart:p.pos]
+	var annotation Annotation
+	switch tag {
+	case "alias":
+		annotation = p.parseAlias()
+	case "cast":
+		annotation = p.parseCast()
+	case "class":
+		annotation = p.parseClass()
+	case "deprecated":
+		annotation = &Deprecated{Description: p.rest()}
+	case "diagnostic":
+		annotation = p.parseDiagnostic()
+	case "field":
+		annotation = p.parseField()
+	case "generic":
+		annotation = p.parseGeneric()
+	case "overload":
+		annotation = p.parseOverload()
+	case "param":
+		annotation = p.parseParam()
+	case "return":
+		annotation = p.parseReturn()
+	case "type":
+		annotation = p.parseTypeAnnotation()
+	default:
+		if !unsupportedTags[tag] {
+			p.errorf(start, p.pos, "Unknown annotation '@%s'", tag)
+		}
+		p.pos = len(p.src)
+		return nil
+	}
+	if !p.atEnd() {
+		p.errorf(p.pos, len(p.src), "Unexpected '%s'", strings.TrimSpace(p.src[p.pos:]))
+	}
+	setRange(annotation, p.rng(start))
+	return annotation
+}
+
+func setRange(annotation Annotation, rng token.Range) {
+	switch a := annotation.(type) {
+	case *Alias:
+		a.Range = rng
+	case *Cast:
+		a.Range = rng
+	case *Class:
+		a.Range = rng
+	case *Deprecated:
+		a.Range = rng
+	case *Diagnostic:
+		a.Range = rng
+	case *Field:
+		a.Range = rng
+	case *Generic:
+		a.Range = rng
+	case *Overload:
+		a.Range = rng
+	case *Param:
+		a.Range = rng
+	case *Return:
+		a.Range = rng
+	case *TypeAnnotation:
+		a.Range = rng
+	}
+}
+
+func (p *parser) parseAlias() *Alias {
+	alias := &Alias{Name: p.parseName("alias name")}
+	if !p.atEnd() {
+		alias.Type = p.parseType(true)
+	}
+	return alias
+}
+
+// parseAliasContinuation parses a `---| Type` line, which adds a member to the
+// alias above it.
+func (p *parser) parseAliasContinuation() {
+	start := p.pos
+	p.pos++ // |
+	var alias *Alias
+	if n := len(p.comment.Annotations); n > 0 {
+		alias, _ = p.comment.Annotations[n-1].(*Alias)
+	}
+	if alias == nil {
+		p.errorf(start, len(p.src), "'---|' must follow an @alias")
+		return
+	}
+	member := p.parsePrimaryType()
+	if member == nil {
+		return
+	}
+	// Anything after the type is a description
+	p.rest()
+	switch typ := alias.Type.(type) {
+	case nil:
+		alias.Type = member
+	case *UnionType:
+		typ.Types = append(typ.Types, member)
+		typ.Range.End = member.End()
+	default:
+		alias.Type = &UnionType{
+			span:  span{token.Range{Start: typ.Pos(), End: member.End()}},
+			Types: []Type{typ, member},
+		}
+	}
+	alias.Range.End = member.End()
+}
+
+func (p *parser) parseCast() *Cast {
+	cast := &Cast{Name: p.parseName("variable name")}
+	if p.accept("+") {
+		cast.Op = "+"
+	} else if p.accept("-") {
+		cast.Op = "-"
+	}
+	cast.Type = p.parseType(true)
+	return cast
+}
+
+func (p *parser) parseClass() *Class {
+	class := &Class{}
+	if p.accept("(exact)") {
+		class.Exact = true
+	}
+	class.Name = p.parseName("class name")
+	if p.accept("<") {
+		class.Params = p.parseGeneric().Params
+		p.expect(">")
+	}
+	if p.accept(":") {
+		for {
+			if parent := p.parseType(false); parent != nil {
+				class.Parents = append(class.Parents, parent)
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	p.rest()
+	return class
+}
+
+var diagnosticActions = map[string]bool{
+	"disable":           true,
+	"enable":            true,
+	"disable-line":      true,
+	"disable-next-line": true,
+}
+
+func (p *parser) parseDiagnostic() *Diagnostic {
+	diagnostic := &Diagnostic{}
+	action := p.parseName("diagnostic action")
+	if action == nil {
+		return diagnostic
+	}
+	diagnostic.Action = action.Name
+	if !diagnosticActions[action.Name] {
+		p.errorf(action.Pos()-p.base, action.End()-p.base, "Unknown diagnostic action '%s'", action.Name)
+	}
+	if p.accept(":") {
+		for {
+			if code := p.parseName("diagnostic code"); code != nil {
+				diagnostic.Codes = append(diagnostic.Codes, code)
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	return diagnostic
+}
+
+var visibilities = map[string]bool{
+	"public":    true,
+	"private":   true,
+	"protected": true,
+	"package":   true,
+}
+
+func (p *parser) parseField() *Field {
+	field := &Field{}
+	p.skipSpace()
+	if p.accept("[") {
+		field.Key = p.parseType(true)
+		p.expect("]")
+	} else {
+		field.Name = p.parseName("field name")
+		if field.Name != nil && visibilities[field.Name.Name] {
+			save := p.pos
+			p.skipSpace()
+			if p.pos < len(p.src) && (isNameByte(p.src[p.pos], true) || p.src[p.pos] == '[') {
+				field.Visibility = field.Name.Name
+				if p.accept("[") {
+					field.Name = nil
+					field.Key = p.parseType(true)
+					p.expect("]")
+				} else {
+					field.Name = p.parseName("field name")
+				}
+			} else {
+				p.pos = save
+			}
+		}
+	}
+	if p.accept("?") {
+		field.Optional = true
+	}
+	field.Type = p.parseType(true)
+	field.Description = p.rest()
+	return field
+}
+
+func (p *parser) parseGeneric() *Generic {
+	generic := &Generic{}
+	for {
+		p.skipSpace()
+		paramStart := p.pos
+		param := &GenericParam{Name: p.parseName("type parameter name")}
+		if param.Name == nil {
+			break
+		}
+		if p.accept(":") {
+			param.Constraint = p.parseType(false)
+		}
+		param.Range = p.rng(paramStart)
+		generic.Params = append(generic.Params, param)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return generic
+}
+
+func (p *parser) parseOverload() *Overload {
+	overload := &Overload{}
+	typ := p.parseType(true)
+	if fn, ok := typ.(*FunctionType); ok {
+		overload.Type = fn
+	} else if typ != nil {
+		p.errorf(typ.Pos()-p.base, typ.End()-p.base, "Expected a function type")
+	}
+	return overload
+}
+
+func (p *parser) parseParam() *Param {
+	param := &Param{}
+	p.skipSpace()
+	nameStart := p.pos
+	if p.accept("...") {
+		param.Name = &Name{span: span{p.rng(nameStart)}, Name: "..."}
+	} else {
+		param.Name = p.parseName("parameter name")
+	}
+	if p.accept("?") {
+		param.Optional = true
+	}
+	param.Type = p.parseType(true)
+	param.Description = p.rest()
+	return param
+}
+
+func (p *parser) parseReturn() *Return {
+	ret := &Return{}
+	for {
+		p.skipSpace()
+		valueStart := p.pos
+		typ := p.parseType(false)
+		if typ == nil {
+			break
+		}
+		value := &ReturnValue{Type: typ}
+		// An optional name
+		save := p.pos
+		p.skipSpace()
+		if p.pos < len(p.src) && isNameByte(p.src[p.pos], true) {
+			value.Name = p.parseName("return value name")
+			// A single word is a name, more words are a description
+			if !p.atEnd() && p.peekByte() != ',' && p.peekByte() != '#' {
+				value.Name = nil
+				p.pos = save
+			}
+		} else {
+			p.pos = save
+		}
+		value.Range = p.rng(valueStart)
+		ret.Values = append(ret.Values, value)
+		if !p.accept(",") {
+			break
+		}
+	}
+	ret.Description = p.rest()
+	return ret
+}
+
+func (p *parser) parseTypeAnnotation() *TypeAnnotation {
+	annotation := &TypeAnnotation{}
+	for {
+		if typ := p.parseType(false); typ != nil {
+			annotation.Types = append(annotation.Types, typ)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return annotation
+}
+
+// parseType parses a union type. If multiReturn is false, a function type
+// inside of it can only have a single return type, so that commas are left
+// for the enclosing list.
+func (p *parser) parseType(multiReturn bool) Type {
+	p.skipSpace()
+	start := p.pos
+	first := p.parsePostfixType(multiReturn)
+	if first == nil {
+		return nil
+	}
+	types := []Type{first}
+	for p.accept("|") {
+		if typ := p.parsePostfixType(multiReturn); typ != nil {
+			types = append(types, typ)
+		}
+	}
+	if len(types) == 1 {
+		return first
+	}
+	return &UnionType{span: span{p.rng(start)}, Types: types}
+}
+
+func (p *parser) parsePostfixType(multiReturn bool) Type {
+	p.skipSpace()
+	start := p.pos
+	typ := p.parsePrimaryTypeWith(multiReturn)
+	if typ == nil {
+		return nil
+	}
+	for {
+		// Postfix operators must directly follow the type
+		if strings.HasPrefix(p.src[p.pos:], "[]") {
+			p.pos += 2
+			typ = &ArrayType{span: span{p.rng(start)}, Elem: typ}
+		} else if p.peekByte() == '?' {
+			p.pos++
+			typ = &OptionalType{span: span{p.rng(start)}, Inner: typ}
+		} else {
+			return typ
+		}
+	}
+}
+
+func (p *parser) parsePrimaryType() Type {
+	return p.parsePrimaryTypeWith(false)
+}
+
+func (p *parser) parsePrimaryTypeWith(multiReturn bool) Type {
+	p.skipSpace()
+	start := p.pos
+	switch b := p.peekByte(); {
+	case b == 0:
+		p.errorf(start, start, "Expected a type")
+		return nil
+	case b == '"' || b == '\'' || b == '`':
+		end := strings.IndexByte(p.src[p.pos+1:], b)
+		if end == -1 {
+			p.errorf(start, len(p.src), "Unterminated string")
+			p.pos = len(p.src)
+			return nil
+		}
+		p.pos += end + 2
+		return &LiteralType{span: span{p.rng(start)}, Value: p.src[start:p.pos]}
+	case '0' <= b && b <= '9' || b == '-':
+		p.pos++
+		for p.pos < len(p.src) && ('0' <= p.src[p.pos] && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		return &LiteralType{span: span{p.rng(start)}, Value: p.src[start:p.pos]}
+	case b == '(':
+		p.pos++
+		typ := p.parseType(true)
+		p.expect(")")
+		return typ
+	case b == '{':
+		return p.parseTableType()
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		vararg := &VarargType{}
+		if p.accept(":") {
+			vararg.Type = p.parseType(false)
+		} else if p.pos < len(p.src) && isNameByte(p.src[p.pos], true) {
+			// `...Type`
+			vararg.Type = p.parsePostfixType(false)
+		}
+		vararg.Range = p.rng(start)
+		return vararg
+	}
+
+	name := p.parseName("a type")
+	if name == nil {
+		p.pos = p.wordEnd()
+		return nil
+	}
+	switch name.Name {
+	case "fun":
+		if p.peekByte() == '(' {
+			return p.parseFunctionType(start, multiReturn)
+		}
+	case "true", "false":
+		return &LiteralType{span: name.span, Value: name.Name}
+	}
+	if p.peekByte() == '<' {
+		p.pos++
+		generic := &GenericType{Name: name}
+		for {
+			if typ := p.parseType(false); typ != nil {
+				generic.Args = append(generic.Args, typ)
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(">")
+		generic.Range = p.rng(start)
+		return generic
+	}
+	return &NamedType{span: name.span, Name: name.Name}
+}
+
+func (p *parser) parseFunctionType(start int, multiReturn bool) *FunctionType {
+	fn := &FunctionType{}
+	p.expect("(")
+	if !p.accept(")") {
+		for {
+			p.skipSpace()
+			paramStart := p.pos
+			param := &FunctionParam{}
+			if p.accept("...") {
+				param.Name = &Name{span: span{p.rng(paramStart)}, Name: "..."}
+			} else {
+				param.Name = p.parseName("parameter name")
+				if param.Name == nil {
+					break
+				}
+			}
+			if p.accept("?") {
+				param.Optional = true
+			}
+			if p.accept(":") {
+				param.Type = p.parseType(false)
+			}
+			param.Range = p.rng(paramStart)
+			fn.Params = append(fn.Params, param)
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(")")
+	}
+	if p.accept(":") {
+		for {
+			if typ := p.parseType(false); typ != nil {
+				fn.Returns = append(fn.Returns, typ)
+			}
+			if !multiReturn || !p.accept(",") {
+				break
+			}
+		}
+	}
+	fn.Range = p.rng(start)
+	return fn
+}
+
+func (p *parser) parseTableType() *TableType {
+	start := p.pos
+	p.pos++ // {
+	tbl := &TableType{}
+	for !p.accept("}") {
+		if p.atEnd() {
+			p.errorf(start, p.pos, "Unterminated table type")
+			break
+		}
+		p.skipSpace()
+		fieldStart := p.pos
+		field := &TableTypeField{}
+		if p.accept("[") {
+			field.Key = p.parseType(true)
+			p.expect("]")
+		} else if field.Name = p.parseName("field name"); field.Name == nil {
+			break
+		}
+		if p.accept("?") {
+			field.Optional = true
+		}
+		p.expect(":")
+		field.Type = p.parseType(false)
+		field.Range = p.rng(fieldStart)
+		tbl.Fields = append(tbl.Fields, field)
+		if !p.accept(",") && !p.accept(";") {
+			p.expect("}")
+			break
+		}
+	}
+	tbl.Range = p.rng(start)
+	return tbl
+}
@@ -0,0 +1,222 @@
+package doc
+
+import (
+	"strings"
+	"testing"
+
+	luaparser "github.com/raiguard/luapls/lua/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseDoc returns the doc comment of the first statement in src.
+func parseDoc(t *testing.T, src string) *Comment {
+	file := luaparser.New(src).ParseFile()
+	require.NotEmpty(t, file.Block.Pairs)
+	return FromTrivia(file.LeadingTrivia(file.Block.Pairs[0].Node.Pos()))
+}
+
+func TestFromTrivia(t *testing.T) {
+	assert.Nil(t, parseDoc(t, "local x"))
+	assert.Nil(t, parseDoc(t, "-- Not a doc comment\nlocal x"))
+	assert.Nil(t, parseDoc(t, "--- Separated by a blank line\n\nlocal x"))
+
+	c := parseDoc(t, "--- Ignored\n\n-- Ordinary\n--- Adds two numbers.\n--- Second line.\nlocal x")
+	require.NotNil(t, c)
+	assert.Equal(t, "Adds two numbers.\nSecond line.", c.Description)
+	assert.Empty(t, c.Errors)
+}
+
+func TestParam(t *testing.T) {
+	src := "---@param x number The first number\n---@param y? string|nil\n---@param ... any\nlocal x"
+	c := parseDoc(t, src)
+	require.Empty(t, c.Errors)
+	params := Find[*Param](c)
+	require.Len(t, params, 3)
+
+	assert.Equal(t, "x", params[0].Name.Name)
+	assert.Equal(t, "number", params[0].Type.(*NamedType).Name)
+	assert.Equal(t, "The first number", params[0].Description)
+	assert.Equal(t, strings.Index(src, "number"), params[0].Type.Pos())
+
+	assert.True(t, params[1].Optional)
+	union := params[1].Type.(*UnionType)
+	require.Len(t, union.Types, 2)
+	assert.Equal(t, strings.Index(src, "string|nil"), union.Pos())
+	assert.Equal(t, strings.Index(src, "string|nil")+len("string|nil"), union.End())
+
+	assert.Equal(t, "...", params[2].Name.Name)
+}
+
+func TestReturn(t *testing.T) {
+	c := parseDoc(t, "---@return number count, string? # The result\nlocal x")
+	require.Empty(t, c.Errors)
+	returns := Find[*Return](c)
+	require.Len(t, returns, 1)
+	values := returns[0].Values
+	require.Len(t, values, 2)
+	assert.Equal(t, "count", values[0].Name.Name)
+	assert.IsType(t, &OptionalType{}, values[1].Type)
+	assert.Nil(t, values[1].Name)
+	assert.Equal(t, "The result", returns[0].Description)
+}
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected Type
+	}{
+		{"string[]", &ArrayType{}},
+		{"table<string, number>", &GenericType{}},
+		{"fun(a: number, b?: string): boolean", &FunctionType{}},
+		{"{ name: string, [number]: boolean }", &TableType{}},
+		{`"a" | "b" | 1`, &UnionType{}},
+		{"(string|number)[]", &ArrayType{}},
+		{"Foo.Bar?", &OptionalType{}},
+		{"true", &LiteralType{}},
+		{"...string", &VarargType{}},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			c := parseDoc(t, "---@type "+test.src+"\nlocal x")
+			require.Empty(t, c.Errors)
+			annotations := Find[*TypeAnnotation](c)
+			require.Len(t, annotations, 1)
+			require.Len(t, annotations[0].Types, 1)
+			typ := annotations[0].Types[0]
+			assert.IsType(t, test.expected, typ)
+			assert.Equal(t, len("---@type ")+len(test.src), typ.End())
+		})
+	}
+
+	// Commas separate the types of a list, not the returns of a function
+	c := parseDoc(t, "---@type fun(a: number, b?: string): boolean, string\nlocal x")
+	types := Find[*TypeAnnotation](c)[0].Types
+	require.Len(t, types, 2)
+	fn := types[0].(*FunctionType)
+	require.Len(t, fn.Params, 2)
+	assert.True(t, fn.Params[1].Optional)
+	assert.Len(t, fn.Returns, 1)
+
+	c = parseDoc(t, "---@overload fun(): boolean, string\nlocal x")
+	assert.Len(t, Find[*Overload](c)[0].Type.Returns, 2)
+}
+
+func TestClass(t *testing.T) {
+	c := parseDoc(t, `---@class (exact) Dog: Animal, Pet
+---@field name string The dog's name
+---@field private age? integer
+---@field [string] boolean
+local Dog = {}`)
+	require.Empty(t, c.Errors)
+	class := Find[*Class](c)[0]
+	assert.True(t, class.Exact)
+	assert.Equal(t, "Dog", class.Name.Name)
+	assert.Len(t, class.Parents, 2)
+
+	fields := Find[*Field](c)
+	require.Len(t, fields, 3)
+	assert.Equal(t, "name", fields[0].Name.Name)
+	assert.Equal(t, "The dog's name", fields[0].Description)
+	assert.Equal(t, "private", fields[1].Visibility)
+	assert.Equal(t, "age", fields[1].Name.Name)
+	assert.True(t, fields[1].Optional)
+	assert.Nil(t, fields[2].Name)
+	assert.IsType(t, &NamedType{}, fields[2].Key)
+
+	c = parseDoc(t, "---@class Map<K, V: table>: Base<K>\nlocal Map = {}")
+	require.Empty(t, c.Errors)
+	class = Find[*Class](c)[0]
+	assert.Equal(t, "Map", class.Name.Name)
+	require.Len(t, class.Params, 2)
+	assert.Equal(t, "V", class.Params[1].Name.Name)
+	assert.NotNil(t, class.Params[1].Constraint)
+	require.Len(t, class.Parents, 1)
+	assert.IsType(t, &GenericType{}, class.Parents[0])
+}
+
+func TestAlias(t *testing.T) {
+	c := parseDoc(t, `---@alias Mode
+---| "r" # Read
+---| "w" # Write
+---| "a"
+local x`)
+	require.Empty(t, c.Errors)
+	alias := Find[*Alias](c)[0]
+	assert.Equal(t, "Mode", alias.Name.Name)
+	union := alias.Type.(*UnionType)
+	assert.Len(t, union.Types, 3)
+}
+
+func TestOtherAnnotations(t *testing.T) {
+	c := parseDoc(t, `---@generic T: table, K
+---@overload fun(a: T): K
+---@deprecated Use bar instead
+---@cast x +string
+---@diagnostic disable-next-line: unused-local, undefined-global
+local x`)
+	require.Empty(t, c.Errors)
+
+	generic := Find[*Generic](c)[0]
+	require.Len(t, generic.Params, 2)
+	assert.NotNil(t, generic.Params[0].Constraint)
+	assert.Nil(t, generic.Params[1].Constraint)
+
+	assert.Len(t, Find[*Overload](c)[0].Type.Params, 1)
+	assert.Equal(t, "Use bar instead", Find[*Deprecated](c)[0].Description)
+
+	cast := Find[*Cast](c)[0]
+	assert.Equal(t, "+", cast.Op)
+	assert.Equal(t, "x", cast.Name.Name)
+
+	diagnostic := Find[*Diagnostic](c)[0]
+	assert.Equal(t, "disable-next-line", diagnostic.Action)
+	require.Len(t, diagnostic.Codes, 2)
+	assert.Equal(t, "undefined-global", diagnostic.Codes[1].Name)
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		message string
+		errText string // The text covered by the error
+	}{
+		{"---@foo bar", "Unknown annotation '@foo'", "@foo"},
+		{"---@param x", "Expected a type", ""},
+		{"---@type fun(a: number", "Expected ')'", ""},
+		{"---@type table<string", "Expected '>'", ""},
+		{"---@type 'abc", "Unterminated string", "'abc"},
+		{"---@overload string", "Expected a function type", "string"},
+		{"---@diagnostic silence: foo", "Unknown diagnostic action 'silence'", "silence"},
+		{"---| 'a'", "'---|' must follow an @alias", "| 'a'"},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			c := parseDoc(t, test.src+"\nlocal x")
+			require.NotEmpty(t, c.Errors)
+			err := c.Errors[0]
+			assert.Equal(t, test.message, err.Message)
+			if test.errText != "" {
+				assert.Equal(t, test.errText, test.src[err.Range.Start:err.Range.End])
+			} else {
+				assert.Equal(t, len(test.src), err.Range.Start)
+			}
+		})
+	}
+}
+
+func TestUnsupportedTags(t *testing.T) {
+	c := parseDoc(t, `---@meta
+---@nodiscard
+---@see other.lua
+---@enum Color
+---@async
+---@private
+---@vararg string
+---@operator add(Vector): Vector
+---@version >5.2, JIT
+---@param x number
+local x`)
+	assert.Empty(t, c.Errors)
+	assert.Len(t, Find[*Param](c), 1)
+}
@@ -18,11 +18,16 @@ type Lexer struct {
 	pos   int    // current position in the input.
 	width int    // width of last rune read.
 
+	dialect    token.Dialect
 	lineBreaks []int
 }
 
 func New(input string) *Lexer {
-	return &Lexer{input: input, pos: 0, lineBreaks: []int{}}
+	return NewWithDialect(input, token.DefaultDialect)
+}
+
+func NewWithDialect(input string, dialect token.Dialect) *Lexer {
+	return &Lexer{input: input, pos: 0, dialect: dialect, lineBreaks: []int{}}
 }
 
 func (l *Lexer) Next() token.Token {
@@ -51,12 +56,16 @@ func (l *Lexer) Next() token.Token {
 	case '>':
 		if l.accept("=") {
 			tok = token.GEQ
+		} else if l.accept(">") {
+			tok = token.SHR
 		} else {
 			tok = token.GT
 		}
 	case '<':
 		if l.accept("=") {
 			tok = token.LEQ
+		} else if l.accept("<") {
+			tok = token.SHL
 		} else {
 			tok = token.LT
 		}
@@ -74,13 +83,23 @@ func (l *Lexer) Next() token.Token {
 	case '+':
 		tok = token.PLUS
 	case '/':
-		tok = token.SLASH
+		if l.accept("/") {
+			tok = token.IDIV
+		} else {
+			tok = token.SLASH
+		}
 	case '*':
 		tok = token.MUL
 	case '~':
 		if l.accept("=") {
 			tok = token.NEQ
+		} else {
+			tok = token.BXOR
 		}
+	case '&':
+		tok = token.BAND
+	case '|':
+		tok = token.BOR
 	case '(':
 		tok = token.LPAREN
 	case ')':
@@ -134,7 +153,7 @@ func (l *Lexer) Next() token.Token {
 				l.ignore()
 			}
 		} else if l.readIdentifier() {
-			if reserved, ok := token.Reserved[l.input[l.start:l.pos]]; ok {
+			if reserved, ok := l.dialect.Keyword(l.input[l.start:l.pos]); ok {
 				tok = reserved
 			} else {
 				tok = token.IDENT
@@ -153,7 +172,11 @@ func (l *Lexer) GetLineBreaks() []int {
 }
 
 func Run(input string) ([]token.Token, []int) {
-	l := New(input)
+	return RunWithDialect(input, token.DefaultDialect)
+}
+
+func RunWithDialect(input string, dialect token.Dialect) ([]token.Token, []int) {
+	l := NewWithDialect(input, dialect)
 	tokens := []token.Token{}
 	for {
 		tok := l.Next()
@@ -270,11 +293,15 @@ func (l *Lexer) readNumber() bool {
 		l.acceptRun(hexDigits)
 	}
 
+	// Consume any trailing identifier characters so that suffixes such as
+	// LuaJIT's `ULL` stay part of the number. The parser validates them.
+	l.readIdentifier()
+
 	return true
 }
 
 func (l *Lexer) readIdentifier() bool {
-	for isIdentifier(l.read()) {
+	for IsIdentifier(l.read()) {
 	}
 	l.backup()
 	return true
@@ -298,7 +325,10 @@ func (l *Lexer) readString(quote rune) bool {
 				continue
 			}
 		}
-		l.read()
+		if l.read() == 0 && l.width == 0 {
+			// Unterminated at the end of the input
+			return false
+		}
 	}
 	return true
 }
@@ -335,6 +365,7 @@ func (l *Lexer) readRawString() bool {
 	return true
 }
 
-func isIdentifier(rune rune) bool {
+// IsIdentifier returns true if rune can be part of an identifier.
+func IsIdentifier(rune rune) bool {
 	return unicode.IsLetter(rune) || unicode.IsDigit(rune) || rune == '_'
 }
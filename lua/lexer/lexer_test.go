@@ -21,8 +21,8 @@ func TestOperators(t *testing.T) {
 		{Type: token.ASSIGN, Literal: "=", Pos: 6},
 		{Type: token.SLASH, Literal: "/", Pos: 7},
 		{Type: token.EQUAL, Literal: "==", Pos: 8},
-		{Type: token.GT, Literal: ">", Pos: 10},
-		{Type: token.GEQ, Literal: ">=", Pos: 11},
+		{Type: token.SHR, Literal: ">>", Pos: 10},
+		{Type: token.ASSIGN, Literal: "=", Pos: 12},
 		{Type: token.LEQ, Literal: "<=", Pos: 13},
 		{Type: token.LT, Literal: "<", Pos: 15},
 		{Type: token.NEQ, Literal: "~=", Pos: 16},
@@ -31,6 +31,25 @@ func TestOperators(t *testing.T) {
 	testLexer(t, input, tokens)
 }
 
+func TestBitwiseOperators(t *testing.T) {
+	input := "&|~//<<>>~=<=>=/>"
+	tokens := []token.Token{
+		{Type: token.BAND, Literal: "&", Pos: 0},
+		{Type: token.BOR, Literal: "|", Pos: 1},
+		{Type: token.BXOR, Literal: "~", Pos: 2},
+		{Type: token.IDIV, Literal: "//", Pos: 3},
+		{Type: token.SHL, Literal: "<<", Pos: 5},
+		{Type: token.SHR, Literal: ">>", Pos: 7},
+		{Type: token.NEQ, Literal: "~=", Pos: 9},
+		{Type: token.LEQ, Literal: "<=", Pos: 11},
+		{Type: token.GEQ, Literal: ">=", Pos: 13},
+		{Type: token.SLASH, Literal: "/", Pos: 15},
+		{Type: token.GT, Literal: ">", Pos: 16},
+		{Type: token.EOF, Literal: "", Pos: 17},
+	}
+	testLexer(t, input, tokens)
+}
+
 func TestKeywords(t *testing.T) {
 	input := "local while for"
 	tokens := []token.Token{
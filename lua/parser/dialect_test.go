@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		input   string
+		dialect token.Dialect
+		errors  []string
+	}{
+		{"goto continue", token.Lua51, []string{"goto is not available in Lua 5.1"}},
+		{"goto continue", token.LuaJIT, nil},
+		{"::continue::", token.Lua51, []string{"Labels are not available in Lua 5.1"}},
+		{"x = 7 // 2", token.Lua52, []string{"Integer division is not available in Lua 5.2"}},
+		{"x = 7 // 2", token.Lua53, nil},
+		{"x = a & ~b", token.LuaJIT, []string{"Bitwise operators are not available in LuaJIT", "Bitwise operators are not available in LuaJIT"}},
+		{"local x <const> = 1", token.Lua53, []string{"Attributes are not available in Lua 5.3"}},
+		{"local x <const> = 1", token.Lua54, nil},
+		{"x = 0x1ULL + 2i", token.LuaJIT, nil},
+		{"x = 0x1ULL", token.Lua54, []string{"Number suffix 'ULL' is only available in LuaJIT"}},
+		{"x = 3abc", token.LuaJIT, []string{"Malformed number '3abc'"}},
+		{"x = 0xA23p-4 + 314.16e-2", token.Lua51, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.dialect.String()+"/"+test.input, func(t *testing.T) {
+			file := NewWithDialect(test.input, test.dialect).ParseFile()
+			assert.Equal(t, test.errors, errorMessages(file.Errors))
+		})
+	}
+}
+
+func errorMessages(errors []ast.Error) []string {
+	var messages []string
+	for _, err := range errors {
+		messages = append(messages, err.Message)
+	}
+	return messages
+}
@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/raiguard/luapls/lua/ast"
 	"github.com/raiguard/luapls/lua/token"
 	"github.com/raiguard/luapls/util"
@@ -15,7 +18,7 @@ func (p *Parser) parseExpression(precedence operatorPrecedence, allowCall bool)
 		left = p.parseIdentifier()
 	case token.LBRACE:
 		left = p.parseTableLiteral()
-	case token.LEN, token.MINUS, token.NOT:
+	case token.BXOR, token.LEN, token.MINUS, token.NOT:
 		left = p.parsePrefixExpression()
 	case token.LPAREN:
 		left = p.parseSurroundingExpression()
@@ -104,6 +107,54 @@ func (p *Parser) parseNameList() ast.Punctuated[*ast.Identifier] {
 	return list
 }
 
+// Identical to parseNameList, but each name may be followed by an attribute.
+// The returned attributes are parallel to the names, and are nil if no name
+// has an attribute.
+func (p *Parser) parseAttNameList() (ast.Punctuated[*ast.Identifier], []*ast.LocalAttribute) {
+	list := ast.Punctuated[*ast.Identifier]{StartPos: p.unit().Pos()}
+	attribs := []*ast.LocalAttribute{}
+	hasAttribs := false
+
+	for {
+		if !p.tokIs(token.IDENT) {
+			break
+		}
+		pair := ast.Pair[*ast.Identifier]{Node: p.parseIdentifier()}
+		var attrib *ast.LocalAttribute
+		if p.tokIs(token.LT) {
+			attrib = p.parseLocalAttribute()
+			hasAttribs = true
+		}
+		attribs = append(attribs, attrib)
+		if p.tokIs(token.COMMA) {
+			pair.Delimeter = p.unit()
+			p.next()
+		}
+		list.Pairs = append(list.Pairs, pair)
+		if pair.Delimeter == nil {
+			break
+		}
+	}
+
+	if !hasAttribs {
+		return list, nil
+	}
+	return list, attribs
+}
+
+func (p *Parser) parseLocalAttribute() *ast.LocalAttribute {
+	attrib := &ast.LocalAttribute{LeftAngle: p.expect(token.LT)}
+	if !p.dialect.HasAttributes() {
+		p.unavailableError(attrib.LeftAngle.Range(), "Attributes are")
+	}
+	attrib.Name = p.parseIdentifier()
+	if name := attrib.Name.Token.Literal; name != "" && !localAttribs[name] {
+		p.addErrorForNode(attrib.Name, fmt.Sprintf("Unknown attribute '%s'", name))
+	}
+	attrib.RightAngle = p.expect(token.GT)
+	return attrib
+}
+
 // Parses a namelist, then an optional vararg
 func (p *Parser) parseParameterList() (ast.Punctuated[*ast.Identifier], *ast.Unit) {
 	return p.parseNameList(), p.accept(token.VARARG)
@@ -156,6 +207,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) *ast.InfixExpression
 		Right:    nil,
 	}
 
+	p.checkOperator(&expression.Operator)
 	precedence := p.tokPrecedence()
 	p.next()
 	expression.Right = p.parseExpression(precedence, true)
@@ -172,6 +224,7 @@ func (p *Parser) parseSurroundingExpression() ast.Expression {
 
 func (p *Parser) parsePrefixExpression() *ast.PrefixExpression {
 	operator := *p.unit()
+	p.checkOperator(&operator)
 	p.next()
 	right := p.parseExpression(PREFIX, true)
 	return &ast.PrefixExpression{Operator: operator, Right: right}
@@ -192,7 +245,57 @@ func (p *Parser) parseNilLiteral() *ast.NilLiteral {
 }
 
 func (p *Parser) parseNumberLiteral() *ast.NumberLiteral {
-	return util.Ptr(ast.NumberLiteral(p.expect(token.NUMBER)))
+	nl := util.Ptr(ast.NumberLiteral(p.expect(token.NUMBER)))
+	p.checkNumberSuffix(nl)
+	return nl
+}
+
+// checkNumberSuffix validates any trailing letters that the lexer included in
+// a number literal.
+func (p *Parser) checkNumberSuffix(nl *ast.NumberLiteral) {
+	literal := nl.Token.Literal
+	digitSet, exponents := "0123456789", "eE"
+	i := 0
+	if strings.HasPrefix(literal, "0x") || strings.HasPrefix(literal, "0X") {
+		digitSet, exponents = "0123456789abcdefABCDEF", "pP"
+		i = 2
+	}
+	for i < len(literal) && strings.IndexByte(digitSet+".", literal[i]) >= 0 {
+		i++
+	}
+	if i < len(literal) && strings.IndexByte(exponents, literal[i]) >= 0 {
+		i++
+		if i < len(literal) && (literal[i] == '+' || literal[i] == '-') {
+			i++
+		}
+		for i < len(literal) && strings.IndexByte("0123456789", literal[i]) >= 0 {
+			i++
+		}
+	}
+	suffix := literal[i:]
+	if suffix == "" {
+		return
+	}
+	if !numberSuffixes[strings.ToLower(suffix)] {
+		p.addErrorForNode(nl, fmt.Sprintf("Malformed number '%s'", literal))
+	} else if !p.dialect.HasNumberSuffixes() {
+		p.addErrorForNode(nl, fmt.Sprintf("Number suffix '%s' is only available in %s", suffix, token.LuaJIT))
+	}
+}
+
+// checkOperator reports operators that are not available in the current
+// dialect.
+func (p *Parser) checkOperator(operator *ast.Unit) {
+	switch operator.Type() {
+	case token.IDIV:
+		if !p.dialect.HasIntegerOperators() {
+			p.unavailableError(operator.Range(), "Integer division is")
+		}
+	case token.BAND, token.BOR, token.BXOR, token.SHL, token.SHR:
+		if !p.dialect.HasIntegerOperators() {
+			p.unavailableError(operator.Range(), "Bitwise operators are")
+		}
+	}
 }
 
 func (p *Parser) parseStringLiteral() *ast.StringLiteral {
@@ -203,6 +306,7 @@ func (p *Parser) parseTableLiteral() *ast.TableLiteral {
 	tl := &ast.TableLiteral{LeftBrace: p.expect(token.LBRACE)}
 
 	if rbrace := p.accept(token.RBRACE); rbrace != nil {
+		tl.Fields.StartPos = rbrace.Pos()
 		tl.RightBrace = *rbrace
 		return tl
 	}
@@ -222,6 +326,17 @@ func (p *Parser) parseVararg() *ast.Vararg {
 	return util.Ptr(ast.Vararg(p.expect(token.VARARG)))
 }
 
+var numberSuffixes = map[string]bool{
+	"i":   true,
+	"ll":  true,
+	"ull": true,
+}
+
+var localAttribs = map[string]bool{
+	"close": true,
+	"const": true,
+}
+
 var tableSep = map[token.TokenType]bool{
 	token.COMMA:     true,
 	token.SEMICOLON: true,
@@ -236,6 +351,10 @@ const (
 	OR
 	AND
 	CMP
+	BOR
+	BXOR
+	BAND
+	SHIFT
 	CONCAT
 	SUM
 	PRODUCT
@@ -252,11 +371,17 @@ var precedences = map[token.TokenType]operatorPrecedence{
 	token.GEQ:    CMP,
 	token.NEQ:    CMP,
 	token.EQUAL:  CMP,
+	token.BOR:    BOR,
+	token.BXOR:   BXOR,
+	token.BAND:   BAND,
+	token.SHL:    SHIFT,
+	token.SHR:    SHIFT,
 	token.CONCAT: CONCAT,
 	token.PLUS:   SUM,
 	token.MINUS:  SUM,
 	token.MUL:    PRODUCT,
 	token.SLASH:  PRODUCT,
+	token.IDIV:   PRODUCT,
 	token.MOD:    PRODUCT,
 	token.NOT:    PREFIX,
 	token.LEN:    PREFIX,
@@ -265,10 +390,14 @@ var precedences = map[token.TokenType]operatorPrecedence{
 
 var infixOperators = map[token.TokenType]bool{
 	token.AND:    true,
+	token.BAND:   true,
+	token.BOR:    true,
+	token.BXOR:   true,
 	token.CONCAT: true,
 	token.EQUAL:  true,
 	token.GEQ:    true,
 	token.GT:     true,
+	token.IDIV:   true,
 	token.LEQ:    true,
 	token.LT:     true,
 	token.MINUS:  true,
@@ -277,6 +406,8 @@ var infixOperators = map[token.TokenType]bool{
 	token.MOD:    true,
 	token.PLUS:   true,
 	token.POW:    true,
+	token.SHL:    true,
+	token.SHR:    true,
 	token.SLASH:  true,
 	token.MUL:    true,
 }
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"sort"
+
 	"github.com/raiguard/luapls/lua/ast"
 	"github.com/raiguard/luapls/lua/token"
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -11,6 +13,11 @@ type File struct {
 	Errors     []ast.Error
 	LineBreaks []int
 	// TODO: Global exports, etc.
+
+	// Used by Reparse
+	dialect token.Dialect
+	units   []ast.Unit
+	stmts   []stmtInfo // Parallel to Block.Pairs
 }
 
 func (f *File) ToPos(position protocol.Position) token.Pos {
@@ -60,3 +67,33 @@ func (f *File) ToProtocolRange(rng token.Range) protocol.Range {
 		End:   f.ToProtocolPos(rng.End),
 	}
 }
+
+// LeadingTrivia returns the comments and whitespace before the token at pos,
+// or nil if no token starts there.
+func (f *File) LeadingTrivia(pos token.Pos) []token.Token {
+	i := sort.Search(len(f.units), func(i int) bool { return f.units[i].Pos() >= pos })
+	if i == len(f.units) || f.units[i].Pos() != pos {
+		return nil
+	}
+	return f.units[i].LeadingTrivia
+}
+
+// Dialect returns the dialect that the file was parsed with.
+func (f *File) Dialect() token.Dialect {
+	return f.dialect
+}
+
+// Comments returns every comment in the file, in order.
+func (f *File) Comments() []token.Token {
+	comments := []token.Token{}
+	for _, unit := range f.units {
+		for _, trivia := range [][]token.Token{unit.LeadingTrivia, unit.TrailingTrivia} {
+			for _, tok := range trivia {
+				if tok.Type == token.COMMENT {
+					comments = append(comments, tok)
+				}
+			}
+		}
+	}
+	return comments
+}
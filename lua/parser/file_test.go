@@ -33,3 +33,12 @@ func assertPositionsMatch(t *testing.T, file *File, position protocol.Position,
 	astPos := file.ToPos(position)
 	assert.Equal(t, pos, astPos)
 }
+
+func TestComments(t *testing.T) {
+	file := New("-- First\nlocal x = 1 -- Trailing\n--[[ Block ]] print(x)\n-- Last\n").ParseFile()
+	literals := []string{}
+	for _, comment := range file.Comments() {
+		literals = append(literals, comment.Literal)
+	}
+	assert.Equal(t, []string{"-- First", "-- Trailing", "--[[ Block ]]", "-- Last"}, literals)
+}
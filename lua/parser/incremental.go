@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// Edit replaces a range of the source with new text. The range is in
+// positions of the source before the edit.
+type Edit struct {
+	Range token.Range
+	Text  string
+}
+
+// stmtInfo records what the parse of a top-level statement depended on.
+type stmtInfo struct {
+	start     token.Pos // The position of the first unit of the statement
+	errors    int       // The number of errors added while parsing the statement
+	lookahead token.Pos // The end of the furthest unit that the parser examined, including trivia
+	next      int       // The index of the unit after the statement
+}
+
+func (p *Parser) parseTopLevelStatement() (ast.Pair[ast.Statement], stmtInfo) {
+	start := p.unit().Pos()
+	errors := len(p.errors)
+	p.lookahead = p.pos
+	pair := ast.Pair[ast.Statement]{Node: p.parseStatement()}
+	if p.tokIs(token.SEMICOLON) {
+		pair.Delimeter = p.unit()
+		p.next()
+	}
+	return pair, stmtInfo{
+		start:     start,
+		errors:    len(p.errors) - errors,
+		lookahead: unitEnd(&p.units[p.lookahead]),
+		next:      p.pos,
+	}
+}
+
+// Reparse parses input, which is the source of old with edit applied. Only the
+// top-level statements that the edit could have affected are parsed again.
+// The statements after them are reused from old with their positions shifted,
+// so old must not be used afterwards. The result is equivalent to parsing input
+// from scratch.
+func Reparse(old *File, input string, edit Edit) File {
+	units, lineBreaks := Run(input, old.dialect)
+	p := &Parser{
+		dialect:    old.dialect,
+		errors:     []ast.Error{},
+		lineBreaks: lineBreaks,
+		units:      units,
+	}
+	if old.units == nil || len(old.stmts) != len(old.Block.Pairs) {
+		return p.ParseFile()
+	}
+	delta := len(edit.Text) - (edit.Range.End - edit.Range.Start)
+
+	file := File{
+		Block:      ast.Block{StartPos: p.unit().Pos()},
+		LineBreaks: lineBreaks,
+		dialect:    old.dialect,
+		units:      units,
+	}
+
+	// Statements that did not look at anything at or after the edit are unchanged
+	kept := 0
+	keptErrors := 0
+	for kept < len(old.stmts) && old.stmts[kept].lookahead < edit.Range.Start {
+		keptErrors += old.stmts[kept].errors
+		kept++
+	}
+	file.Block.Pairs = append(file.Block.Pairs, old.Block.Pairs[:kept]...)
+	file.stmts = append(file.stmts, old.stmts[:kept]...)
+	p.errors = append(p.errors, old.Errors[:keptErrors]...)
+
+	// The units before the edit are unchanged, so their indices are too
+	if kept > 0 {
+		p.pos = old.stmts[kept-1].next
+	}
+	p.lookahead = p.pos
+
+	next := kept
+	for !blockEnd[p.unit().Type()] {
+		pos := p.unit().Pos()
+		for next < len(old.stmts) {
+			oldPos := old.stmts[next].start
+			if oldPos >= edit.Range.End && oldPos+delta >= pos {
+				break
+			}
+			next++
+		}
+		if next < len(old.stmts) && old.stmts[next].start+delta == pos && old.leadingTriviaMatches(next, p.unit(), delta) {
+			file.reuse(old, next, delta, len(units)-len(old.units))
+			skipped := 0
+			for _, info := range old.stmts[:next] {
+				skipped += info.errors
+			}
+			for _, err := range old.Errors[skipped:] {
+				err.Range.Start += delta
+				err.Range.End += delta
+				p.errors = append(p.errors, err)
+			}
+			break
+		}
+		pair, info := p.parseTopLevelStatement()
+		file.Block.Pairs = append(file.Block.Pairs, pair)
+		file.stmts = append(file.stmts, info)
+	}
+
+	file.Errors = p.errors
+	return file
+}
+
+// leadingTriviaMatches reports whether the first unit of the given top-level
+// statement has the same leading trivia as unit, once shifted. The leading
+// trivia depends on the source before the statement, so it may have changed
+// even if the statement itself did not.
+func (f *File) leadingTriviaMatches(stmt int, unit *ast.Unit, delta int) bool {
+	pos := f.stmts[stmt].start
+	i := sort.Search(len(f.units), func(i int) bool { return f.units[i].Pos() >= pos })
+	if i == len(f.units) || f.units[i].Pos() != pos {
+		return false
+	}
+	oldTrivia := f.units[i].LeadingTrivia
+	if len(oldTrivia) != len(unit.LeadingTrivia) {
+		return false
+	}
+	for j, tok := range oldTrivia {
+		newTok := unit.LeadingTrivia[j]
+		if tok.Type != newTok.Type || tok.Literal != newTok.Literal || tok.Pos+delta != newTok.Pos {
+			return false
+		}
+	}
+	return true
+}
+
+// reuse appends the top-level statements of old, starting at the given index,
+// shifted by delta. unitDelta is the difference in the number of units.
+func (f *File) reuse(old *File, start int, delta int, unitDelta int) {
+	// Shift them together, since statements can share trivia
+	rest := ast.Block{Pairs: old.Block.Pairs[start:]}
+	ast.Shift(&rest, delta)
+	f.Block.Pairs = append(f.Block.Pairs, rest.Pairs...)
+	for i := start; i < len(old.Block.Pairs); i++ {
+		info := old.stmts[i]
+		info.start += delta
+		info.lookahead += delta
+		info.next += unitDelta
+		f.stmts = append(f.stmts, info)
+	}
+}
+
+// unitEnd returns the end of the unit including its trailing trivia.
+func unitEnd(unit *ast.Unit) token.Pos {
+	if len(unit.TrailingTrivia) > 0 {
+		return unit.TrailingTrivia[len(unit.TrailingTrivia)-1].End()
+	}
+	return unit.End()
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/stretchr/testify/require"
+)
+
+var editFragments = []string{
+	"", "x", "y2", " ", "\n", "\n\n", "\t", "end", "end\n", "local y = 2\n", "function f()\n", "function(a, ...) ",
+	"--", "-- comment\n", "--[[", "]]", "[==[", "]==]", "\"", "'", "(", ")", "{", "}", "[", "]", ",", ";", ".", ":",
+	"=", "==", "+", "..", "if a then ", "elseif ", "else ", "while true do ", "repeat ", "until x", "for i = 1, 2 do ",
+	"for k, v in pairs(t) do ", "goto l ", "::l::", "return ", "return\n", "1", "0x1F", "3.5e2", "nil", "not ", "#",
+	"do ", "break ", "x = 1\n", "print(x)\n", "a.b:c(d)", "{ a = 1, [2] = 3, 4 }",
+}
+
+var incrementalSources = []string{
+	"",
+	"local x = 1\n",
+	`local foo, bar = 1, "two"
+local function add(a, b)
+  return a + b
+end
+
+-- Comment
+print(add(foo, 2)) -- Trailing comment
+for i = 1, 10 do
+  if i > 5 then
+    break
+  end
+end
+x = { a = 1, [2] = 3; "four" }
+repeat x = x - 1 until x < 0
+while x do x = nil end
+do local y = 2 end
+`,
+}
+
+func TestReparse(t *testing.T) {
+	sources := incrementalSources
+	for _, path := range []string{"../../demo.lua", "../../demo2.lua", "../../demo3.lua"} {
+		if src, err := os.ReadFile(path); err == nil {
+			sources = append(sources, string(src))
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 300; trial++ {
+		src := sources[rng.Intn(len(sources))]
+		file := New(src).ParseFile()
+		for step := 0; step < 5; step++ {
+			start := rng.Intn(len(src) + 1)
+			end := min(start+rng.Intn(12), len(src))
+			edit := Edit{
+				Range: token.Range{Start: start, End: end},
+				Text:  editFragments[rng.Intn(len(editFragments))],
+			}
+			newSrc := src[:start] + edit.Text + src[end:]
+
+			reparsed := Reparse(&file, newSrc, edit)
+			expected := New(newSrc).ParseFile()
+			if fileJSON(t, &expected) != fileJSON(t, &reparsed) {
+				t.Fatalf("Reparse differs from a full parse\n\nSource:\n%s\n\nEdit: %+v\n\nNew source:\n%s", src, edit, newSrc)
+			}
+
+			src, file = newSrc, reparsed
+		}
+	}
+}
+
+func TestReparseReusesStatements(t *testing.T) {
+	src := "local a = 1\nlocal b = 2\nlocal c = 3\n"
+	file := New(src).ParseFile()
+	first, last := file.Block.Pairs[0].Node, file.Block.Pairs[2].Node
+
+	newSrc := "local a = 1\nlocal b = 222\nlocal c = 3\n"
+	reparsed := Reparse(&file, newSrc, Edit{Range: token.Range{Start: 22, End: 23}, Text: "222"})
+
+	require.Len(t, reparsed.Block.Pairs, 3)
+	require.Same(t, first, reparsed.Block.Pairs[0].Node)
+	require.Same(t, last, reparsed.Block.Pairs[2].Node)
+	require.Equal(t, 26, last.Pos())
+}
+
+func fileJSON(t *testing.T, file *File) string {
+	bytes, err := json.Marshal(struct {
+		Block      any
+		Errors     any
+		LineBreaks any
+	}{&file.Block, file.Errors, file.LineBreaks})
+	require.NoError(t, err)
+	return string(bytes)
+}
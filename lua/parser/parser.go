@@ -1,4 +1,4 @@
-// Package Parser implements a recursive descent parser for Lua 5.2. It is
+// Package Parser implements a recursive descent parser for Lua 5.4. It is
 // heavily based on "Writing an Interpreter in Go" by Thorston Ball.
 // https://interpreterbook.com/
 
@@ -17,15 +17,22 @@ import (
 )
 
 type Parser struct {
+	dialect    token.Dialect
 	errors     []ast.Error
 	lineBreaks []int
 	units      []ast.Unit
 	pos        int
+	lookahead  int // The furthest unit that the parser has examined
 }
 
 func New(input string) *Parser {
-	units, lineBreaks := Run(input)
+	return NewWithDialect(input, token.DefaultDialect)
+}
+
+func NewWithDialect(input string, dialect token.Dialect) *Parser {
+	units, lineBreaks := Run(input, dialect)
 	p := &Parser{
+		dialect:    dialect,
 		errors:     []ast.Error{},
 		lineBreaks: lineBreaks,
 		units:      units,
@@ -34,9 +41,9 @@ func New(input string) *Parser {
 	return p
 }
 
-func Run(input string) ([]ast.Unit, []int) {
+func Run(input string, dialect token.Dialect) ([]ast.Unit, []int) {
 	// Consume all tokens and convert them into units
-	tokens, lineBreaks := lexer.Run(input)
+	tokens, lineBreaks := lexer.RunWithDialect(input, dialect)
 	units := []ast.Unit{}
 	u := ast.Unit{
 		LeadingTrivia:  []token.Token{},
@@ -83,20 +90,37 @@ func (p *Parser) Errors() []ast.Error {
 }
 
 func (p *Parser) ParseFile() File {
-	return File{
-		Block:      p.parseBlock(),
-		Errors:     p.errors,
+	file := File{
+		Block:      ast.Block{StartPos: p.unit().Pos()},
 		LineBreaks: p.lineBreaks,
+		dialect:    p.dialect,
+		units:      p.units,
+	}
+	for !blockEnd[p.unit().Type()] {
+		pair, info := p.parseTopLevelStatement()
+		file.Block.Pairs = append(file.Block.Pairs, pair)
+		file.stmts = append(file.stmts, info)
 	}
+	file.Errors = p.errors
+	return file
 }
 
 func (p *Parser) unit() *ast.Unit {
 	return &p.units[p.pos]
 }
 
+func (p *Parser) peek() *ast.Unit {
+	if p.pos < len(p.units)-1 {
+		p.lookahead = max(p.lookahead, p.pos+1)
+		return &p.units[p.pos+1]
+	}
+	return p.unit()
+}
+
 func (p *Parser) next() ast.Unit {
 	if p.pos < len(p.units)-1 {
 		p.pos++
+		p.lookahead = max(p.lookahead, p.pos)
 	}
 	return p.units[p.pos]
 }
@@ -134,6 +158,7 @@ func (p *Parser) parseFunctionCall(name ast.Expression) *ast.FunctionCall {
 	fc.LeftParen = util.Ptr(p.expect(token.LPAREN))
 
 	if rparen := p.accept(token.RPAREN); rparen != nil {
+		fc.Args.StartPos = rparen.Pos()
 		fc.RightParen = rparen
 		return fc
 	}
@@ -149,7 +174,9 @@ func (p *Parser) accept(tokenType token.TokenType) *ast.Unit {
 	if !p.tokIs(tokenType) {
 		return nil
 	}
-	return util.Ptr(p.next())
+	unit := *p.unit()
+	p.next()
+	return &unit
 }
 
 func (p *Parser) expect(tokenType token.TokenType) ast.Unit {
@@ -160,6 +187,7 @@ func (p *Parser) expect(tokenType token.TokenType) ast.Unit {
 		if limit > len(p.units)-1 {
 			limit = len(p.units) - 1
 		}
+		p.lookahead = max(p.lookahead, limit-1)
 		for i := p.pos + 1; i < limit; i++ {
 			unit := p.units[i]
 			if unit.Type() == tokenType {
@@ -179,8 +207,10 @@ func (p *Parser) expect(tokenType token.TokenType) ast.Unit {
 			unit := &p.units[p.pos]
 			for _, tok := range errorTokens {
 				p.errors = append(p.errors, ast.Error{
-					Message: fmt.Sprintf("Extraneous %s", token.TokenStr[tok.Type]),
-					Range:   tok.Range(),
+					Message:  fmt.Sprintf("Extraneous %s", token.TokenStr[tok.Type]),
+					Range:    tok.Range(),
+					Code:     syntaxError,
+					Severity: ast.SeverityError,
 				})
 				unit.LeadingTrivia = append(unit.LeadingTrivia, tok)
 			}
@@ -190,13 +220,15 @@ func (p *Parser) expect(tokenType token.TokenType) ast.Unit {
 				Token: token.Token{
 					Type:    tokenType,
 					Literal: "",
-					Pos:     initialPos,
+					Pos:     p.unit().Pos(),
 				},
 				TrailingTrivia: []token.Token{},
 			}
 			p.errors = append(p.errors, ast.Error{
-				Message: fmt.Sprintf("Missing %s", token.TokenStr[tokenType]),
-				Range:   fakeTok.Range(),
+				Message:  fmt.Sprintf("Missing %s", token.TokenStr[tokenType]),
+				Range:    fakeTok.Range(),
+				Code:     syntaxError,
+				Severity: ast.SeverityError,
 			})
 			p.next()
 			return fakeTok
@@ -218,12 +250,29 @@ func (p *Parser) invalidTokenError() {
 	p.addError(fmt.Sprintf("Unexpected %s", token.TokenStr[p.unit().Type()]))
 }
 
+// The codes of parse errors
+const (
+	syntaxError       = "syntax-error"
+	unsupportedSyntax = "unsupported-syntax"
+)
+
 func (p *Parser) addError(message string) {
-	p.errors = append(p.errors, ast.Error{Range: p.unit().Token.Range(), Message: message})
+	p.errors = append(p.errors, ast.Error{Range: p.unit().Token.Range(), Message: message, Code: syntaxError, Severity: ast.SeverityError})
 }
 
 func (p *Parser) addErrorForNode(node ast.Node, message string) {
-	p.errors = append(p.errors, ast.Error{Range: ast.Range(node), Message: message})
+	p.errors = append(p.errors, ast.Error{Range: ast.Range(node), Message: message, Code: syntaxError, Severity: ast.SeverityError})
+}
+
+// unavailableError reports syntax that exists in Lua, but not in the current
+// dialect.
+func (p *Parser) unavailableError(rng token.Range, feature string) {
+	p.errors = append(p.errors, ast.Error{
+		Range:    rng,
+		Message:  fmt.Sprintf("%s not available in %s", feature, p.dialect),
+		Code:     unsupportedSyntax,
+		Severity: ast.SeverityError,
+	})
 }
 
 func (p *Parser) tokIs(tokenType token.TokenType) bool {
@@ -18,6 +18,12 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseFunctionStatement(nil)
 	case token.GOTO:
 		return p.parseGotoStatement()
+	case token.IDENT:
+		// goto is not a keyword in Lua 5.1, but it is almost certainly meant as one
+		if p.unit().Token.Literal == "goto" && p.peek().Type() == token.IDENT {
+			p.unit().Token.Type = token.GOTO
+			return p.parseGotoStatement()
+		}
 	case token.IF:
 		return p.parseIfStatement()
 	case token.LABEL:
@@ -105,10 +111,14 @@ func (p *Parser) parseForStatement() ast.Statement {
 	if bareLoop {
 		var start, finish ast.Pair[ast.Expression]
 		if len(exps.Pairs) < 2 || len(exps.Pairs) > 3 {
-			p.addError("Expected 2 to 3 expressions")
+			p.addErrorForNode(&exps, "Expected 2 to 3 expressions")
 		}
 		start = exps.Pairs[0]
-		finish = exps.Pairs[1]
+		if len(exps.Pairs) > 1 {
+			finish = exps.Pairs[1]
+		} else {
+			finish = ast.Pair[ast.Expression]{Node: &ast.Invalid{Position: exps.End()}}
+		}
 		var step *ast.Pair[ast.Expression]
 		if len(exps.Pairs) > 2 {
 			step = &exps.Pairs[2]
@@ -161,6 +171,9 @@ func (p *Parser) parseFunctionStatement(localTok *ast.Unit) *ast.FunctionStateme
 
 func (p *Parser) parseGotoStatement() *ast.GotoStatement {
 	gotoTok := p.expect(token.GOTO)
+	if !p.dialect.HasGoto() {
+		p.unavailableError(gotoTok.Range(), "goto is")
+	}
 	name := p.parseIdentifier()
 	return &ast.GotoStatement{
 		GotoTok: gotoTok,
@@ -214,6 +227,9 @@ func (p *Parser) parseLabelStatement() *ast.LabelStatement {
 	leadingLabelTok := p.expect(token.LABEL)
 	name := p.parseIdentifier()
 	trailingLabelTok := p.expect(token.LABEL)
+	if !p.dialect.HasGoto() {
+		p.unavailableError(token.Range{Start: leadingLabelTok.Pos(), End: trailingLabelTok.End()}, "Labels are")
+	}
 	return &ast.LabelStatement{
 		LeadingLabelTok:  leadingLabelTok,
 		Name:             name,
@@ -222,10 +238,8 @@ func (p *Parser) parseLabelStatement() *ast.LabelStatement {
 }
 
 func (p *Parser) parseLocalStatement(localTok ast.Unit) *ast.LocalStatement {
-	ls := &ast.LocalStatement{
-		LocalTok: localTok,
-		Names:    p.parseNameList(),
-	}
+	ls := &ast.LocalStatement{LocalTok: localTok}
+	ls.Names, ls.Attribs = p.parseAttNameList()
 	if assignTok := p.accept(token.ASSIGN); assignTok != nil {
 		ls.AssignTok = assignTok
 		ls.Exps = util.Ptr(p.parseExpressionList())
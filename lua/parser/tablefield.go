@@ -53,7 +53,8 @@ func (p *Parser) parseTableField() ast.TableField {
 
 	name, ok := expr.(*ast.Identifier)
 	if !ok {
-		panic("TODO: Table key was not an identifier!")
+		p.addErrorForNode(expr, "Missing brackets around expression key")
+		return &ast.TableArrayField{Expr: p.parseExpression(LOWEST, true)}
 	}
 
 	expr = p.parseExpression(LOWEST, true)
@@ -0,0 +1,83 @@
+package token
+
+import "strings"
+
+// Dialect is a version or flavor of Lua. It controls which syntax the lexer
+// and parser accept.
+type Dialect int
+
+const (
+	Lua51 Dialect = iota
+	Lua52
+	Lua53
+	Lua54
+	LuaJIT
+)
+
+const DefaultDialect = Lua54
+
+var DialectStr = map[Dialect]string{
+	Lua51:  "Lua 5.1",
+	Lua52:  "Lua 5.2",
+	Lua53:  "Lua 5.3",
+	Lua54:  "Lua 5.4",
+	LuaJIT: "LuaJIT",
+}
+
+func (d Dialect) String() string {
+	return DialectStr[d]
+}
+
+// ParseDialect accepts the names used in settings and on the command line,
+// such as "5.1", "Lua 5.4", "lua53" or "luajit".
+func ParseDialect(name string) (Dialect, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimPrefix(name, "lua")
+	name = strings.TrimSpace(name)
+	switch name {
+	case "5.1", "51":
+		return Lua51, true
+	case "5.2", "52":
+		return Lua52, true
+	case "5.3", "53":
+		return Lua53, true
+	case "5.4", "54":
+		return Lua54, true
+	case "jit":
+		return LuaJIT, true
+	}
+	return DefaultDialect, false
+}
+
+// Keyword returns the token type for ident if it is reserved in this dialect.
+func (d Dialect) Keyword(ident string) (TokenType, bool) {
+	typ, ok := Reserved[ident]
+	if typ == GOTO && d == Lua51 {
+		// goto is an ordinary identifier in Lua 5.1
+		return IDENT, false
+	}
+	return typ, ok
+}
+
+// HasGoto reports whether goto statements and labels are available.
+func (d Dialect) HasGoto() bool {
+	return d != Lua51
+}
+
+// HasIntegerOperators reports whether the integer division and bitwise
+// operators are available.
+func (d Dialect) HasIntegerOperators() bool {
+	return d == Lua53 || d == Lua54
+}
+
+// HasAttributes reports whether local variables can have attributes such as
+// `<const>`.
+func (d Dialect) HasAttributes() bool {
+	return d == Lua54
+}
+
+// HasNumberSuffixes reports whether number literals can have the LuaJIT
+// `LL`, `ULL` and `i` suffixes.
+func (d Dialect) HasNumberSuffixes() bool {
+	return d == LuaJIT
+}
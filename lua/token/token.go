@@ -47,11 +47,15 @@ const (
 	// Operators
 	AND
 	ASSIGN
+	BAND
+	BOR
+	BXOR // Also unary bitwise not
 	POW
 	CONCAT
 	EQUAL
 	GEQ
 	GT
+	IDIV
 	LEN
 	LEQ
 	LT
@@ -61,6 +65,8 @@ const (
 	OR
 	MOD
 	PLUS
+	SHL
+	SHR
 	SLASH
 	MUL
 
@@ -164,10 +170,14 @@ var TokenStr = map[TokenType]string{
 	// Operators
 	AND:    "and",
 	ASSIGN: "assign",
+	BAND:   "band",
+	BOR:    "bor",
+	BXOR:   "bxor",
 	POW:    "pow",
 	EQUAL:  "equal",
 	GEQ:    "geq",
 	GT:     "gt",
+	IDIV:   "idiv",
 	LEN:    "len",
 	LEQ:    "leq",
 	LT:     "lt",
@@ -177,6 +187,8 @@ var TokenStr = map[TokenType]string{
 	OR:     "or",
 	MOD:    "mod",
 	PLUS:   "plus",
+	SHL:    "shl",
+	SHR:    "shr",
 	SLASH:  "slash",
 	MUL:    "mul",
 
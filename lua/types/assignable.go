@@ -0,0 +1,294 @@
+package types
+
+// Equal returns whether a and b are structurally the same type. The order of
+// union members does not matter.
+func Equal(a, b Type) bool {
+	return equal(a, b, map[[2]Type]bool{})
+}
+
+func equal(a, b Type, seen map[[2]Type]bool) bool {
+	if a == b {
+		return true
+	}
+	key := [2]Type{a, b}
+	if seen[key] {
+		return true
+	}
+	// The pair is only assumed equal while it is being compared
+	seen[key] = true
+	defer delete(seen, key)
+	switch a := a.(type) {
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok || len(a.Params) != len(b.Params) {
+			return false
+		}
+		for i := range a.Params {
+			if !equal(typeOrUnknown(a.Params[i].Type), typeOrUnknown(b.Params[i].Type), seen) {
+				return false
+			}
+		}
+		if (a.Vararg == nil) != (b.Vararg == nil) || (a.Vararg != nil && !equal(a.Vararg, b.Vararg, seen)) {
+			return false
+		}
+		if a.Returns == nil || b.Returns == nil {
+			return a.Returns == nil && b.Returns == nil
+		}
+		return equalTuples(a.Returns, b.Returns, seen)
+	case *Table:
+		b, ok := b.(*Table)
+		if !ok {
+			return false
+		}
+		// Classes are only equal to themselves, and instances to instances
+		// with the same type arguments
+		if a.Name != "" || b.Name != "" {
+			return sameInstance(a, b, seen)
+		}
+		if len(a.Fields) != len(b.Fields) || (a.Value == nil) != (b.Value == nil) {
+			return false
+		}
+		if a.Value != nil && (!equal(a.Key, b.Key, seen) || !equal(a.Value, b.Value, seen)) {
+			return false
+		}
+		for i := range a.Fields {
+			field := b.field(a.Fields[i].Name)
+			if field == nil || !equal(typeOrUnknown(a.Fields[i].Type), typeOrUnknown(field.Type), seen) {
+				return false
+			}
+		}
+		return true
+	case *Union:
+		b, ok := b.(*Union)
+		if !ok || len(a.Types) != len(b.Types) {
+			return false
+		}
+	outer:
+		for _, member := range a.Types {
+			for _, other := range b.Types {
+				if equal(member, other, seen) {
+					continue outer
+				}
+			}
+			return false
+		}
+		return true
+	case *TypeParam:
+		// Type parameters are only equal to themselves
+		return false
+	}
+	// The remaining types have no structure
+	return kind(a) == kind(b)
+}
+
+// IsAssignable returns whether a value of type from can be used where a value
+// of type to is expected.
+//
+//   - any and unknown are assignable to and from everything.
+//   - A union is assignable if all of its members are, and a type is
+//     assignable to a union if it is assignable to one of its members.
+//   - A table is assignable to another if it has all of its fields. Missing
+//     fields are allowed if they are optional. If the other table has an
+//     indexer, the table's indexer and the fields that it covers must be
+//     assignable to it. `table` accepts every table.
+//   - A function is assignable to another if it accepts its parameters and
+//     returns something assignable to its return type. `function` accepts
+//     every function.
+func IsAssignable(from, to Type) bool {
+	return isAssignable(from, to, map[[2]Type]bool{})
+}
+
+func isAssignable(from, to Type, seen map[[2]Type]bool) bool {
+	if from == to {
+		return true
+	}
+	switch to.(type) {
+	case *Any, *Unknown:
+		return true
+	}
+	switch from.(type) {
+	case *Any, *Unknown:
+		return true
+	}
+	// Recursive tables are assumed assignable on the second visit. A pair
+	// that has been checked before is checked again, since it may have failed.
+	key := [2]Type{from, to}
+	if seen[key] {
+		return true
+	}
+	seen[key] = true
+	defer delete(seen, key)
+
+	if from, ok := from.(*Union); ok {
+		for _, member := range from.Types {
+			if !isAssignable(member, to, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	if to, ok := to.(*Union); ok {
+		for _, member := range to.Types {
+			if isAssignable(from, member, seen) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// A type parameter is used as its constraint. Only the type parameter
+	// itself is assignable to it.
+	if from, ok := from.(*TypeParam); ok {
+		return from.Constraint == nil || isAssignable(from.Constraint, to, seen)
+	}
+	switch to := to.(type) {
+	case *TypeParam:
+		return false
+	case *Function:
+		from, ok := from.(*Function)
+		if !ok {
+			return false
+		}
+		if len(from.TypeParams) > 0 {
+			// A generic function is used with the parameters of the other
+			args := &Tuple{}
+			for _, param := range to.Params {
+				args.Types = append(args.Types, typeOrUnknown(param.Type))
+			}
+			from = instantiateFunction(from, args)
+		}
+		for i, param := range to.Params {
+			// Extra arguments are ignored by the function, and extra parameters
+			// are common in callbacks, so neither is checked
+			if i >= len(from.Params) {
+				break
+			}
+			if !isAssignable(typeOrUnknown(param.Type), typeOrUnknown(from.Params[i].Type), seen) {
+				return false
+			}
+		}
+		if to.Vararg != nil && from.Vararg != nil && !isAssignable(to.Vararg, from.Vararg, seen) {
+			return false
+		}
+		if to.Returns == nil || from.Returns == nil {
+			return true
+		}
+		return isTupleAssignable(from.Returns, to.Returns, seen)
+	case *Table:
+		from, ok := from.(*Table)
+		if !ok {
+			return false
+		}
+		// A class is only assignable to itself and its ancestors. Other
+		// tables are compared by their fields, including inherited ones.
+		if from.Name != "" && to.Name != "" {
+			return from.inherits(to)
+		}
+		for _, field := range to.Fields {
+			fromField := from.lookup(field.Name)
+			if fromField == nil {
+				if !isAssignable(&Nil{}, typeOrUnknown(field.Type), seen) {
+					return false
+				}
+				continue
+			}
+			if !isAssignable(typeOrUnknown(fromField.Type), typeOrUnknown(field.Type), seen) {
+				return false
+			}
+		}
+		if to.Value == nil {
+			return true
+		}
+		if from.Value != nil && (!isAssignable(from.Key, to.Key, seen) || !isAssignable(from.Value, to.Value, seen)) {
+			return false
+		}
+		if isAssignable(&String{}, to.Key, seen) {
+			for _, field := range from.Fields {
+				if to.field(field.Name) == nil && !isAssignable(typeOrUnknown(field.Type), to.Value, seen) {
+					return false
+				}
+			}
+		} else if from.Value == nil && len(from.Fields) > len(to.Fields) {
+			// A record is not an array
+			return false
+		}
+		return true
+	}
+	return kind(from) == kind(to)
+}
+
+func equalTuples(a, b *Tuple, seen map[[2]Type]bool) bool {
+	if len(a.Types) != len(b.Types) || (a.Rest == nil) != (b.Rest == nil) {
+		return false
+	}
+	for i := range a.Types {
+		if !equal(a.Types[i], b.Types[i], seen) {
+			return false
+		}
+	}
+	return a.Rest == nil || equal(a.Rest, b.Rest, seen)
+}
+
+// IsTupleAssignable returns whether the values of from can be used where the
+// values of to are expected, such as arguments for parameters.
+func IsTupleAssignable(from, to *Tuple) bool {
+	return isTupleAssignable(from, to, map[[2]Type]bool{})
+}
+
+func isTupleAssignable(from, to *Tuple, seen map[[2]Type]bool) bool {
+	for i, typ := range to.Types {
+		if !isAssignable(from.At(i), typ, seen) {
+			return false
+		}
+	}
+	if to.Rest == nil {
+		return true
+	}
+	for _, typ := range from.Types[min(len(to.Types), len(from.Types)):] {
+		if !isAssignable(typ, to.Rest, seen) {
+			return false
+		}
+	}
+	return from.Rest == nil || isAssignable(from.Rest, to.Rest, seen)
+}
+
+// kind returns a name for the concrete type of typ.
+func kind(typ Type) string {
+	switch typ.(type) {
+	case *Any:
+		return "any"
+	case *Boolean:
+		return "boolean"
+	case *Function:
+		return "function"
+	case *Nil:
+		return "nil"
+	case *Number:
+		return "number"
+	case *String:
+		return "string"
+	case *Table:
+		return "table"
+	case *Union:
+		return "union"
+	case *Unknown:
+		return "unknown"
+	}
+	return ""
+}
+
+func typeOrUnknown(typ Type) Type {
+	if typ == nil {
+		return &Unknown{}
+	}
+	return typ
+}
+
+func (t *Table) field(name string) *NameAndType {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
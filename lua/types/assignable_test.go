@@ -0,0 +1,101 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAssignable(t *testing.T) {
+	point := &Table{Fields: []NameAndType{{Name: "x", Type: &Number{}}, {Name: "y", Type: &Number{}}}}
+	xOnly := &Table{Fields: []NameAndType{{Name: "x", Type: &Number{}}}}
+	optional := &Table{Fields: []NameAndType{{Name: "z", Type: NewOptional(&String{})}}}
+	numberFn := &Function{Params: []NameAndType{{Name: "a", Type: &Number{}}}, Returns: NewTuple(&String{})}
+	wideFn := &Function{Params: []NameAndType{{Name: "a", Type: NewUnion(&Number{}, &String{})}}, Returns: NewTuple(&String{})}
+
+	tests := []struct {
+		name     string
+		from, to Type
+		expected bool
+	}{
+		{"same primitive", &Number{}, &Number{}, true},
+		{"different primitives", &Number{}, &String{}, false},
+		{"to any", &Number{}, &Any{}, true},
+		{"from any", &Any{}, &Number{}, true},
+		{"from unknown", &Unknown{}, &String{}, true},
+		{"nil to optional", &Nil{}, NewOptional(&Number{}), true},
+		{"nil to non-optional", &Nil{}, &Number{}, false},
+		{"member to union", &String{}, NewUnion(&Number{}, &String{}), true},
+		{"union to member", NewUnion(&Number{}, &String{}), &String{}, false},
+		{"union to wider union", NewUnion(&Number{}, &Nil{}), NewUnion(&Nil{}, &String{}, &Number{}), true},
+		{"wider table", point, xOnly, true},
+		{"narrower table", xOnly, point, false},
+		{"missing optional field", xOnly, optional, true},
+		{"table to table", point, &Table{}, true},
+		{"function to function", numberFn, &Function{}, true},
+		{"contravariant params", wideFn, numberFn, true},
+		{"contravariant params reversed", numberFn, wideFn, false},
+		{"function to table", numberFn, &Table{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, IsAssignable(test.from, test.to))
+		})
+	}
+
+	// Recursive types terminate
+	node := &Table{}
+	node.Fields = []NameAndType{{Name: "next", Type: NewOptional(node)}}
+	other := &Table{}
+	other.Fields = []NameAndType{{Name: "next", Type: NewOptional(other)}}
+	assert.True(t, IsAssignable(node, other))
+	assert.True(t, Equal(node, other))
+
+	// A nested pair that failed in one union member fails in the others too
+	stringX := &Table{Fields: []NameAndType{{Name: "x", Type: &String{}}}}
+	numberX := &Table{Fields: []NameAndType{{Name: "x", Type: &Number{}}}}
+	wrapped := &Table{Fields: []NameAndType{{Name: "inner", Type: stringX}}}
+	first := &Table{Fields: []NameAndType{{Name: "inner", Type: numberX}}}
+	second := &Table{Fields: []NameAndType{{Name: "inner", Type: numberX}}}
+	assert.False(t, IsAssignable(wrapped, &Union{Types: []Type{first, second}}))
+	assert.False(t, Equal(&Union{Types: []Type{wrapped, first}}, &Union{Types: []Type{first, second}}))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(&Number{}, &Number{}))
+	assert.False(t, Equal(&Number{}, &Any{}))
+	assert.True(t, Equal(NewUnion(&Number{}, &String{}), NewUnion(&String{}, &Number{})))
+	assert.False(t, Equal(NewUnion(&Number{}, &String{}), NewOptional(&Number{})))
+}
+
+func TestAssignmentChecks(t *testing.T) {
+	env := newTestEnvironment(`---@param a number
+---@param b string?
+local function f(a, b) end
+f(1, "b")
+f(1, nil)
+f("a", 1)
+---@type number
+local x = 1
+---@type string
+local y = 1
+x = "x"
+local q = nil
+q = 5
+local n = 1
+n = "n"
+---@param p number
+local function g(p) p = "p" end
+`)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message)
+	}
+	assert.ElementsMatch(t, []string{
+		"Cannot use 'string' as 'number' in argument.",
+		"Cannot use 'number' as 'string?' in argument.",
+		"Cannot assign 'number' to 'string'",
+		"Cannot assign 'string' to 'number'",
+		"Cannot assign 'string' to 'number'",
+	}, errors)
+}
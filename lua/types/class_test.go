@@ -0,0 +1,85 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClasses(t *testing.T) {
+	src := `---@class Animal
+---@field name string
+local Animal = {}
+Animal.__index = Animal
+
+---@param name string
+function Animal.new(name)
+  local self = setmetatable({}, Animal)
+  self.name = name
+  return self
+end
+
+function Animal:speak()
+  local speaker = self
+  return self.name
+end
+
+---@class Dog: Animal
+local Dog = setmetatable({}, {__index = Animal})
+Dog.__index = Dog
+
+function Dog:fetch()
+  local inherited = self.name
+  return true
+end
+
+---@type Dog
+local dog
+local said = dog:speak()
+local fetched = dog:fetch()
+local instance = Animal.new("cat")
+local instanceName = instance.name
+
+---@type Animal
+local animal = dog
+---@type Dog
+local notDog = animal
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "Animal", typeAt("Animal = {}"))
+	assert.Equal(t, "Animal", typeAt("speaker ="))
+	assert.Equal(t, "function(self: Animal) → string", typeAt("speak()\n  local"))
+	assert.Equal(t, "string", typeAt("inherited ="))
+	assert.Equal(t, "string", typeAt("said ="))
+	assert.Equal(t, "boolean", typeAt("fetched ="))
+	assert.Equal(t, "string", typeAt("instanceName ="))
+
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message)
+	}
+	assert.Equal(t, []string{"Cannot assign 'Animal' to 'Dog'"}, errors)
+
+	assert.Equal(t, []*Table{env.Classes["Animal"]}, env.Classes["Dog"].Parents)
+}
+
+func TestClassAssignability(t *testing.T) {
+	base := &Table{Name: "Base", Fields: []NameAndType{{Name: "x", Type: &Number{}}}}
+	derived := &Table{Name: "Derived", Parents: []*Table{base}}
+	other := &Table{Name: "Other", Fields: []NameAndType{{Name: "x", Type: &Number{}}}}
+	instance := &Table{Metatable: &Table{Fields: []NameAndType{{Name: "__index", Type: base}}}}
+
+	assert.True(t, IsAssignable(derived, base))
+	assert.False(t, IsAssignable(base, derived))
+	assert.False(t, IsAssignable(other, base))
+	assert.True(t, IsAssignable(instance, base))
+	assert.False(t, Equal(base, other))
+}
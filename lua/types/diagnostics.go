@@ -0,0 +1,108 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/doc"
+)
+
+// The codes of the errors found by type checking. They are stable, so that
+// they can be configured and suppressed by name.
+const (
+	assignTypeMismatch   = "assign-type-mismatch"
+	breakOutsideLoop     = "break-outside-loop"
+	callNonCallable      = "call-non-callable"
+	deprecated           = "deprecated"
+	forRangeTypeMismatch = "for-range-type-mismatch"
+	globalWrite          = "global-write"
+	indexNil             = "index-nil"
+	indexNonTable        = "index-non-table"
+	indexTypeMismatch    = "index-type-mismatch"
+	invalidVararg        = "invalid-vararg"
+	missingParameter     = "missing-parameter"
+	moduleNotFound       = "module-not-found"
+	notIterable          = "not-iterable"
+	operatorTypeMismatch = "operator-type-mismatch"
+	paramTypeMismatch    = "param-type-mismatch"
+	redundantParameter   = "redundant-parameter"
+	redundantReturnValue = "redundant-return-value"
+	returnTypeMismatch   = "return-type-mismatch"
+	stringCoercion       = "string-coercion"
+	undefinedField       = "undefined-field"
+	undefinedGlobal      = "undefined-global"
+	undefinedLabel       = "undefined-label"
+	unimplemented        = "unimplemented"
+	unusedFunction       = "unused-function"
+	unusedLabel          = "unused-label"
+	unusedLocal          = "unused-local"
+	unusedParameter      = "unused-parameter"
+)
+
+// severities are the default severities of the codes. Other codes are
+// warnings.
+var severities = map[string]ast.Severity{
+	// Lua refuses to compile these
+	breakOutsideLoop: ast.SeverityError,
+	invalidVararg:    ast.SeverityError,
+	undefinedLabel:   ast.SeverityError,
+
+	deprecated:     ast.SeverityHint,
+	stringCoercion: ast.SeverityInformation,
+	unimplemented:  ast.SeverityHint,
+	// Editors fade unnecessary code, so these don't need to stand out
+	unusedFunction:  ast.SeverityHint,
+	unusedLabel:     ast.SeverityHint,
+	unusedLocal:     ast.SeverityHint,
+	unusedParameter: ast.SeverityHint,
+}
+
+// addError reports an error with the given code at node, and returns it so
+// that related information can be added.
+func (e *Environment) addError(code string, node ast.Node, messageFmt string, messageArgs ...any) *ast.Error {
+	severity, ok := severities[code]
+	if !ok {
+		severity = ast.SeverityWarning
+	}
+	e.Errors = append(e.Errors, ast.Error{
+		Message:  fmt.Sprintf(messageFmt, messageArgs...),
+		Range:    ast.Range(node),
+		Code:     code,
+		Severity: severity,
+	})
+	return &e.Errors[len(e.Errors)-1]
+}
+
+// markDeprecated records that the variable declared by ident is deprecated, if
+// the statement is documented as such.
+func (e *Environment) markDeprecated(stmt ast.Statement, ident *ast.Identifier, isGlobal bool) {
+	for _, annotation := range doc.Find[*doc.Deprecated](e.Docs[stmt]) {
+		if isGlobal {
+			e.deprecatedGlobals[ident.Token.Literal] = annotation.Description
+		} else {
+			e.deprecated[ident] = annotation.Description
+		}
+	}
+}
+
+// checkDeprecated reports a use of a deprecated variable. def is the
+// declaration of a local variable, or nil for a global.
+func (e *Environment) checkDeprecated(ident *ast.Identifier, def *ast.Identifier) {
+	name := ident.Token.Literal
+	description, ok := e.deprecatedGlobals[name]
+	if def != nil {
+		description, ok = e.deprecated[def]
+	}
+	if !ok || ident == def {
+		return
+	}
+	message := fmt.Sprintf("'%s' is deprecated", name)
+	if description != "" {
+		message += ": " + description
+	}
+	err := e.addError(deprecated, ident, "%s", message)
+	err.Tags = []ast.Tag{ast.TagDeprecated}
+	if def != nil {
+		err.Related = []ast.RelatedInfo{{Message: fmt.Sprintf("'%s' is declared here", name), Range: ast.Range(def)}}
+	}
+}
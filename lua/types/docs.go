@@ -0,0 +1,247 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/doc"
+	"github.com/raiguard/luapls/lua/parser"
+)
+
+// parseDocs parses the doc comment of every statement in the file.
+func parseDocs(file *parser.File) map[ast.Statement]*doc.Comment {
+	docs := map[ast.Statement]*doc.Comment{}
+	ast.Walk(&file.Block, func(node ast.Node) bool {
+		pair, ok := node.(*ast.Pair[ast.Statement])
+		if !ok {
+			return true
+		}
+		if comment := doc.FromTrivia(file.LeadingTrivia(pair.Node.Pos())); comment != nil {
+			docs[pair.Node] = comment
+		}
+		return true
+	})
+	return docs
+}
+
+// resolveClasses creates a table type for every @class annotation in the
+// file, then resolves their parents and @field annotations.
+func (e *Environment) resolveClasses() {
+	comments := map[*doc.Class]*doc.Comment{}
+	for _, comment := range e.Docs {
+		for _, class := range doc.Find[*doc.Class](comment) {
+			if class.Name == nil || e.Classes[class.Name.Name] != nil {
+				continue
+			}
+			e.Classes[class.Name.Name] = &Table{Name: class.Name.Name}
+			e.ownTables[e.Classes[class.Name.Name]] = true
+			comments[class] = comment
+		}
+	}
+	for class, comment := range comments {
+		tbl := e.Classes[class.Name.Name]
+		// `---@class Name<T>` or `---@generic T`
+		params := class.Params
+		for _, generic := range doc.Find[*doc.Generic](comment) {
+			params = append(params, generic.Params...)
+		}
+		tbl.TypeParams = e.resolveTypeParams(params)
+		for _, parent := range class.Parents {
+			if parentTbl, ok := e.resolveDocType(parent).(*Table); ok && parentTbl != tbl {
+				tbl.Parents = append(tbl.Parents, parentTbl)
+			}
+		}
+		for _, field := range doc.Find[*doc.Field](comment) {
+			if field.Type == nil {
+				continue
+			}
+			fieldTyp := e.resolveDocType(field.Type)
+			if field.Optional {
+				fieldTyp = NewOptional(fieldTyp)
+			}
+			if field.Name != nil {
+				tbl.setField(field.Name.Name, nil, fieldTyp)
+			} else if field.Key != nil {
+				tbl.Key = NewUnion(tbl.Key, e.resolveDocType(field.Key))
+				tbl.Value = NewUnion(tbl.Value, fieldTyp)
+			}
+		}
+		e.popTypeParams()
+	}
+}
+
+// resolveFunctionType types a function from its @param and @return
+// annotations, then resolves its body. Methods have an implicit self
+// parameter, whose type is already set.
+func (e *Environment) resolveFunctionType(comment *doc.Comment, self *ast.Identifier, params *ast.Punctuated[*ast.Identifier], vararg *ast.Unit, body *ast.Block) *Function {
+	paramDocs := map[string]*doc.Param{}
+	for _, param := range doc.Find[*doc.Param](comment) {
+		if param.Name != nil {
+			paramDocs[param.Name.Name] = param
+		}
+	}
+	typ := &Function{Params: []NameAndType{}}
+	if self != nil {
+		// Methods can use the type parameters of their class
+		if class, ok := e.Types[self].(*Table); ok {
+			e.pushTypeParams(class.TypeParams)
+			defer e.popTypeParams()
+		}
+	}
+	var generics []*doc.GenericParam
+	for _, generic := range doc.Find[*doc.Generic](comment) {
+		generics = append(generics, generic.Params...)
+	}
+	typ.TypeParams = e.resolveTypeParams(generics)
+	defer e.popTypeParams()
+	if self != nil {
+		selfTyp := typeOrUnknown(e.Types[self])
+		if param := paramDocs["self"]; param != nil && param.Type != nil {
+			selfTyp = e.addType(self, e.resolveDocType(param.Type))
+			e.annotated[self] = true
+		}
+		typ.Params = append(typ.Params, NameAndType{Name: "self", Def: self, Type: selfTyp})
+	}
+	for _, pair := range params.Pairs {
+		name := pair.Node.Token.Literal
+		var paramTyp Type = &Unknown{}
+		if param := paramDocs[name]; param != nil && param.Type != nil {
+			paramTyp = e.resolveDocType(param.Type)
+			e.annotated[pair.Node] = true
+			if param.Optional {
+				paramTyp = NewOptional(paramTyp)
+			}
+		}
+		e.addType(pair.Node, paramTyp)
+		typ.Params = append(typ.Params, NameAndType{Name: name, Def: pair.Node, Type: paramTyp})
+	}
+	if vararg != nil {
+		typ.Vararg = &Unknown{}
+		if param := paramDocs["..."]; param != nil && param.Type != nil {
+			typ.Vararg = e.resolveDocType(param.Type)
+		}
+	}
+	for _, ret := range doc.Find[*doc.Return](comment) {
+		if typ.Returns == nil {
+			typ.Returns = &Tuple{}
+		}
+		for _, value := range ret.Values {
+			e.addDocValue(typ.Returns, value.Type)
+		}
+	}
+	e.resolveFunctionBody(typ, body)
+	return typ
+}
+
+// resolveDocType converts an annotation type expression to a type.
+func (e *Environment) resolveDocType(typ doc.Type) Type {
+	switch typ := typ.(type) {
+	case *doc.NamedType:
+		switch typ.Name {
+		case "any":
+			return &Any{}
+		case "boolean":
+			return &Boolean{}
+		case "function":
+			return &Function{Params: []NameAndType{}}
+		case "nil":
+			return &Nil{}
+		case "integer", "number":
+			return &Number{}
+		case "string":
+			return &String{}
+		case "table":
+			return &Table{}
+		}
+		if param := e.findTypeParam(typ.Name); param != nil {
+			return param
+		}
+		if class := e.Classes[typ.Name]; class != nil {
+			if len(class.TypeParams) > 0 {
+				return instantiate(class, nil)
+			}
+			return class
+		}
+		// TODO: Aliases
+	case *doc.LiteralType:
+		switch {
+		case typ.Value == "true" || typ.Value == "false":
+			return &Boolean{}
+		case typ.Value[0] == '"' || typ.Value[0] == '\'' || typ.Value[0] == '`':
+			return &String{}
+		default:
+			return &Number{}
+		}
+	case *doc.FunctionType:
+		fn := &Function{Params: []NameAndType{}, Returns: &Tuple{}}
+		for _, param := range typ.Params {
+			var paramTyp Type = &Unknown{}
+			if param.Type != nil {
+				paramTyp = e.resolveDocType(param.Type)
+			}
+			if param.Optional {
+				paramTyp = NewOptional(paramTyp)
+			}
+			if param.Name.Name == "..." {
+				fn.Vararg = paramTyp
+				continue
+			}
+			fn.Params = append(fn.Params, NameAndType{Name: param.Name.Name, Type: paramTyp})
+		}
+		for _, ret := range typ.Returns {
+			e.addDocValue(fn.Returns, ret)
+		}
+		return fn
+	case *doc.TableType:
+		tbl := &Table{}
+		for _, field := range typ.Fields {
+			fieldTyp := e.resolveDocType(field.Type)
+			if field.Optional {
+				fieldTyp = NewOptional(fieldTyp)
+			}
+			if field.Name != nil {
+				tbl.Fields = append(tbl.Fields, NameAndType{Name: field.Name.Name, Type: fieldTyp})
+			} else {
+				tbl.Key = NewUnion(tbl.Key, e.resolveDocType(field.Key))
+				tbl.Value = NewUnion(tbl.Value, fieldTyp)
+			}
+		}
+		return tbl
+	case *doc.ArrayType:
+		return &Table{Key: &Number{}, Value: e.resolveDocType(typ.Elem)}
+	case *doc.GenericType:
+		if typ.Name.Name == "table" && len(typ.Args) == 2 {
+			return &Table{Key: e.resolveDocType(typ.Args[0]), Value: e.resolveDocType(typ.Args[1])}
+		}
+		args := make([]Type, len(typ.Args))
+		for i, arg := range typ.Args {
+			args[i] = e.resolveDocType(arg)
+		}
+		if class := e.Classes[typ.Name.Name]; class != nil && len(class.TypeParams) > 0 {
+			return instantiate(class, args)
+		}
+		return &Table{}
+	case *doc.OptionalType:
+		return NewOptional(e.resolveDocType(typ.Inner))
+	case *doc.VarargType:
+		if typ.Type == nil {
+			return &Unknown{}
+		}
+		return e.resolveDocType(typ.Type)
+	case *doc.UnionType:
+		members := make([]Type, len(typ.Types))
+		for i, member := range typ.Types {
+			members[i] = e.resolveDocType(member)
+		}
+		return NewUnion(members...)
+	}
+	return &Unknown{}
+}
+
+// addDocValue appends an annotated value to a tuple. A vararg type sets the
+// type of the rest of the values.
+func (e *Environment) addDocValue(tuple *Tuple, typ doc.Type) {
+	if _, ok := typ.(*doc.VarargType); ok {
+		tuple.Rest = e.resolveDocType(typ)
+		return
+	}
+	tuple.Types = append(tuple.Types, e.resolveDocType(typ))
+}
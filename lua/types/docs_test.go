@@ -0,0 +1,46 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocTypes(t *testing.T) {
+	src := `---@param a number
+---@param b string
+---@return boolean
+local function foo(a, b, c)
+  local d = a
+end
+
+---@type string
+local e = "e"
+
+---@param x integer
+local bar = function(x) end
+
+---@type numbr[
+local f
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "function(a: number, b: string, c: unknown) → boolean", typeAt("foo"))
+	assert.Equal(t, "number", typeAt("d ="))
+	assert.Equal(t, "string", typeAt("e ="))
+	assert.Equal(t, "function(x: number)", typeAt("bar"))
+
+	// Errors in doc comments are reported
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message)
+	}
+	assert.Contains(t, errors, "Unexpected '['")
+}
@@ -2,33 +2,121 @@ package types
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/doc"
 	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
 )
 
+// Options control optional diagnostics and the environment of the file.
+type Options struct {
+	// Allow strings in arithmetic without a warning. Lua converts them to
+	// numbers at runtime.
+	StringCoercion bool
+	// Global variables that are defined outside of the workspace. Their
+	// types are unknown.
+	Globals []string
+}
+
 type Environment struct {
-	file   *parser.File
-	Types  map[ast.Node]Type
-	Errors []ast.Error
-	Nodes  []ast.Node
+	Options    Options
+	file       *parser.File
+	Scopes     *Scopes
+	Types      map[ast.Node]Type
+	References map[*ast.Identifier][]Reference // Keyed by definition
+	Globals    map[string][]Reference
+	// The types of global variables, including the standard library
+	GlobalTypes map[string]Type
+	Classes     map[string]*Table
+	Docs        map[ast.Statement]*doc.Comment
+	Errors      []ast.Error
+	Nodes       []ast.Node
+	// The value that the file returns to require
+	Module Type
+	// The names of the modules loaded with require, by their argument
+	Requires map[*ast.StringLiteral]string
+	// Require returns the value of the module with the given name, or nil if
+	// it is not found. Required modules are unknown if it is not set.
+	Require func(name string) Type
+	// GlobalType returns the type of a global variable that another file
+	// assigns, or nil if none does.
+	GlobalType func(name string) Type
+
+	functions         []*functionFrame    // The functions being resolved, innermost last
+	moduleReturns     []*Tuple            // The values of the file's return statements
+	deprecated        map[ast.Node]string // Descriptions of deprecated locals, by definition
+	deprecatedGlobals map[string]string
+	topLevelGlobals   map[string]bool                     // Globals assigned outside of functions
+	assignedFields    map[*ast.Identifier]map[string]bool // Fields assigned through each local, by its definition
+	labels            map[*ast.LabelStatement]bool        // Whether a goto targets each label
+	importedGlobals   map[string]Type                     // Copies of the globals that other files assign
+	ownTables         map[*Table]bool                     // The tables and classes that this file creates
+	annotated         map[*ast.Identifier]bool            // Locals and parameters with an annotated type
+	narrowings        []narrowing                         // Innermost last
+	typeParams        []map[string]*TypeParam             // The type parameters in scope, innermost last
+	isLibrary         bool                                // The standard library does not load itself
 }
 
 func NewEnvironment(file *parser.File) Environment {
 	return Environment{
-		file:   file,
-		Types:  map[ast.Node]Type{},
-		Errors: []ast.Error{},
-		Nodes:  []ast.Node{},
+		file:        file,
+		Scopes:      BuildScopes(&file.Block),
+		Docs:        parseDocs(file),
+		Types:       map[ast.Node]Type{},
+		References:  map[*ast.Identifier][]Reference{},
+		Globals:     map[string][]Reference{},
+		GlobalTypes: map[string]Type{},
+		Classes:     map[string]*Table{},
+		Requires:    map[*ast.StringLiteral]string{},
+		Errors:      []ast.Error{},
+		Nodes:       []ast.Node{},
 	}
 }
 
 func (c *Environment) ResolveTypes() {
 	clear(c.Types)
-	clear(c.Errors)
+	clear(c.References)
+	clear(c.Globals)
+	clear(c.GlobalTypes)
+	clear(c.Classes)
+	clear(c.Requires)
+	c.Errors = []ast.Error{}
 	c.Nodes = []ast.Node{}
+	c.narrowings = []narrowing{{}}
+	c.typeParams = nil
+	c.moduleReturns = nil
+	c.deprecated = map[ast.Node]string{}
+	c.deprecatedGlobals = map[string]string{}
+	c.labels = map[*ast.LabelStatement]bool{}
+	c.importedGlobals = map[string]Type{}
+	c.ownTables = map[*Table]bool{}
+	c.annotated = map[*ast.Identifier]bool{}
 
+	for _, comment := range c.Docs {
+		c.Errors = append(c.Errors, comment.Errors...)
+	}
+	slices.SortFunc(c.Errors, func(a, b ast.Error) int { return a.Range.Start - b.Range.Start })
+
+	if !c.isLibrary {
+		library := resolveLibrary(c.file.Dialect())
+		maps.Copy(c.GlobalTypes, library.GlobalTypes)
+		maps.Copy(c.Classes, library.Classes)
+	}
+	for _, name := range c.Options.Globals {
+		if _, ok := c.GlobalTypes[name]; !ok {
+			c.GlobalTypes[name] = &Unknown{}
+		}
+	}
+	c.findTopLevelGlobals()
+	c.findAssignedFields()
+	c.resolveClasses()
 	c.resolveBlockTypes(&c.file.Block)
+	c.resolveModule()
+	c.resolveReferences()
+	c.checkUnused()
 }
 
 func (e *Environment) resolveBlockTypes(block *ast.Block) {
@@ -44,135 +132,152 @@ func (e *Environment) resolveStmtType(stmt ast.Statement) {
 	defer e.popNode()
 	switch stmt := stmt.(type) {
 	case *ast.AssignmentStatement:
-		for i := 0; i < len(stmt.Vars.Pairs); i++ {
-			leftVar := stmt.Vars.Pairs[i]
-			if i >= len(stmt.Exps.Pairs) {
-				e.addType(leftVar.Node, &Unknown{})
-				continue
+		var declared []doc.Type
+		for _, annotation := range doc.Find[*doc.TypeAnnotation](e.Docs[stmt]) {
+			declared = annotation.Types
+		}
+		values := e.resolveExprListValues(&stmt.Exps)
+		for i, pair := range stmt.Vars.Pairs {
+			typ := values.At(i)
+			if i < len(declared) {
+				// The annotated type replaces the assigned type
+				declaredTyp := e.resolveDocType(declared[i])
+				if !IsAssignable(typ, declaredTyp) {
+					e.addError(assignTypeMismatch, pair.Node, "Cannot assign '%s' to '%s'", typ, declaredTyp)
+				}
+				typ = declaredTyp
 			}
-			exp := stmt.Exps.Pairs[i]
-			typ := e.resolveExprType(exp.Node)
-			leftTyp := e.resolveExprType(leftVar.Node)
-			if leftTyp != nil && typ != nil && leftTyp != typ {
-				e.Errors = append(e.Errors, ast.Error{Message: fmt.Sprintf("Cannot assign '%s' to '%s'", typ, leftTyp), Range: ast.Range(leftVar.Node)})
-				e.addType(leftVar.Node, leftTyp)
+			if ident, ok := pair.Node.(*ast.Identifier); ok {
+				if def := e.localDef(ident); def != nil {
+					// Check against the annotated type, then narrow to the
+					// assigned type. Inferred types can change.
+					if declared := e.Types[def]; e.annotated[def] && declared != nil && !IsAssignable(typ, declared) {
+						err := e.addError(assignTypeMismatch, ident, "Cannot assign '%s' to '%s'", typ, declared)
+						err.Related = []ast.RelatedInfo{{Message: fmt.Sprintf("'%s' is declared here", ident.Token.Literal), Range: ast.Range(def)}}
+						e.addType(ident, declared)
+						continue
+					}
+					e.addType(ident, typ)
+					e.narrow(narrowing{def: typ})
+					continue
+				}
+				e.assignGlobal(ident, typ)
 				continue
 			}
-			if typ != nil {
-				e.addType(leftVar.Node, typ)
-			} else {
-				e.addType(leftVar.Node, &Unknown{})
+			// Fields are created from the assigned type
+			e.addType(pair.Node, typ)
+			leftTyp := e.resolveExprType(pair.Node)
+			if leftTyp != nil && !IsAssignable(typ, leftTyp) {
+				e.addError(assignTypeMismatch, pair.Node, "Cannot assign '%s' to '%s'", typ, leftTyp)
+				e.addType(pair.Node, leftTyp)
+				continue
 			}
+			e.addType(pair.Node, typ)
 		}
+	case *ast.BreakStatement:
+		e.checkBreak(stmt)
+	case *ast.DoStatement:
+		e.resolveBlockTypes(&stmt.Body)
 	case *ast.ForStatement:
-		typ := e.resolveExprType(stmt.Start.Node)
-		if typ == nil {
-			e.addType(stmt.Name, &Unknown{})
-			return
-		}
-		if _, ok := typ.(*Number); !ok {
-			e.addError(&stmt.Start, "Range expressions must be of type 'number'")
-			return
+		e.resolveForRange(stmt)
+		e.resolveLoopBody(&stmt.Body, narrowing{})
+	case *ast.ForInStatement:
+		e.resolveForIn(stmt)
+	case *ast.FunctionCall:
+		e.resolveExprValues(stmt)
+		e.narrowAssert(stmt)
+	case *ast.FunctionStatement:
+		var self *ast.Identifier
+		if name, ok := stmt.Name.(*ast.IndexExpression); ok && name.LeftIndexer.Type() == token.COLON {
+			if binding := e.Scopes.Lookup("self", stmt.LeftParen.Pos()); binding != nil && binding.Kind == SelfBinding {
+				self = binding.Ident
+				// self is the table that the method is declared on
+				e.addType(self, typeOrUnknown(e.resolveExprType(name.Prefix)))
+			}
 		}
-		e.addType(stmt.Name, typ)
-		finishTyp := e.resolveExprType(stmt.Finish.Node)
-		if typ != finishTyp {
-			e.addError(&stmt.Finish, "Range end must be of type '%s'", typ)
+		global, isGlobal := stmt.Name.(*ast.Identifier)
+		isGlobal = isGlobal && stmt.LocalTok == nil && e.localDef(global) == nil
+		if isGlobal && e.GlobalTypes[global.Token.Literal] == nil {
+			// The function can call itself
+			e.GlobalTypes[global.Token.Literal] = &Unknown{}
 		}
-		if stmt.Step != nil {
-			stepTyp := e.resolveExprType(stmt.Step.Node)
-			if typ != stepTyp {
-				e.addError(stmt.Step, "Range step must be of type '%s'", typ)
+		typ := e.resolveFunctionType(e.Docs[stmt], self, &stmt.Params, stmt.Vararg, &stmt.Body)
+		e.addType(stmt, typ)
+		switch name := stmt.Name.(type) {
+		case *ast.Identifier:
+			e.addType(name, typ)
+			if isGlobal {
+				e.GlobalTypes[name.Token.Literal] = typ
 			}
+			e.markDeprecated(stmt, name, isGlobal)
+		case *ast.IndexExpression:
+			// Adds the field to the table
+			e.resolveExprType(name)
 		}
-	case *ast.FunctionCall:
-		e.resolveFunctionCallType(stmt)
-	case *ast.FunctionStatement:
-		// typ := &Function{
-		// 	Params: []NameAndType{},
-		// 	Return: nil,
-		// }
-		// comments := stmt.GetComments()
-		// for _, param := range stmt.Params {
-		// 	template := fmt.Sprintf("@param %s", param.Literal)
-		// 	defStart := strings.Index(comments, template)
-		// 	if defStart == -1 {
-		// 		e.addType(param, &Any{})
-		// 		continue
-		// 	}
-		// 	// This is awful
-		// 	def := strings.TrimSpace(strings.Split(comments[defStart+len(template):], "\n")[0])
-		// 	var paramTyp Type = &Unknown{}
-		// 	switch def {
-		// 	case "any":
-		// 		paramTyp = &Any{}
-		// 	case "boolean":
-		// 		paramTyp = &Boolean{}
-		// 	case "number":
-		// 		paramTyp = &Number{}
-		// 	case "string":
-		// 		paramTyp = &String{}
-		// 	}
-		// 	e.addType(param, paramTyp)
-		// 	typ.Params = append(typ.Params, NameAndType{
-		// 		Name: param.Literal,
-		// 		Type: paramTyp,
-		// 	})
-		// 	// TODO: Custom types
-		// }
-		// {
-		// 	template := "@return"
-		// 	defStart := strings.Index(comments, template)
-		// 	if defStart != -1 {
-		// 		// This is awful
-		// 		def := strings.TrimSpace(strings.Split(comments[defStart+len(template):], "\n")[0])
-		// 		switch def {
-		// 		case "any":
-		// 			typ.Return = &Any{}
-		// 		case "boolean":
-		// 			typ.Return = &Boolean{}
-		// 		case "number":
-		// 			typ.Return = &Number{}
-		// 		case "string":
-		// 			typ.Return = &String{}
-		// 		}
-		// 	}
-		// }
-		// e.addType(stmt, typ)
-		// // TODO: Parse index expression
-		// e.addType(stmt.Left, typ)
-
-		// e.resolveExprType(stmt.Left)
 	case *ast.LocalStatement:
-		for i := 0; i < len(stmt.Names.Pairs); i++ {
-			ident := stmt.Names.Pairs[i]
-			if stmt.Exps == nil {
+		var declared []doc.Type
+		for _, annotation := range doc.Find[*doc.TypeAnnotation](e.Docs[stmt]) {
+			declared = annotation.Types
+		}
+		var values *Tuple
+		if stmt.Exps != nil {
+			values = e.resolveExprListValues(stmt.Exps)
+		}
+		// The first variable of a @class is the class table
+		var class *Table
+		for _, annotation := range doc.Find[*doc.Class](e.Docs[stmt]) {
+			if annotation.Name != nil {
+				class = e.Classes[annotation.Name.Name]
+			}
+		}
+		for i, pair := range stmt.Names.Pairs {
+			e.markDeprecated(stmt, pair.Node, false)
+			if i == 0 && class != nil {
+				if values != nil {
+					class.merge(values.At(0))
+				}
+				e.addType(pair.Node, class)
+				e.annotated[pair.Node] = true
 				continue
 			}
-			if i >= len(stmt.Exps.Pairs) {
-				e.addType(ident.Node, &Unknown{})
+			var declaredTyp Type
+			if i < len(declared) {
+				declaredTyp = e.resolveDocType(declared[i])
+				e.annotated[pair.Node] = true
+			}
+			if values == nil {
+				if declaredTyp != nil {
+					e.addType(pair.Node, declaredTyp)
+				}
 				continue
 			}
-			exp := stmt.Exps.Pairs[i]
-			typ := e.resolveExprType(exp.Node)
-			leftTyp := e.resolveExprType(ident.Node)
-			if leftTyp != nil && typ != nil && leftTyp != typ {
-				e.Errors = append(e.Errors, ast.Error{Message: fmt.Sprintf("Cannot assign '%s' to '%s'", typ, leftTyp), Range: ast.Range(ident.Node)})
-				e.addType(ident.Node, leftTyp)
+			typ := values.At(i)
+			if declaredTyp == nil {
+				e.addType(pair.Node, typ)
 				continue
 			}
-			if typ != nil {
-				e.addType(ident.Node, typ)
-			} else {
-				e.addType(ident.Node, &Unknown{})
+			if !IsAssignable(typ, declaredTyp) {
+				e.addError(assignTypeMismatch, pair.Node, "Cannot assign '%s' to '%s'", typ, declaredTyp)
 			}
+			e.addType(pair.Node, declaredTyp)
 		}
-	case *ast.ReturnStatement:
-		for _, expr := range stmt.Exps.Pairs {
-			e.resolveExprType(expr.Node)
+	case *ast.GotoStatement:
+		e.checkGoto(stmt)
+	case *ast.IfStatement:
+		e.resolveIf(stmt)
+	case *ast.LabelStatement:
+		if _, ok := e.labels[stmt]; !ok {
+			e.labels[stmt] = false
 		}
+	case *ast.SemicolonStatement:
+	case *ast.RepeatStatement:
+		e.resolveRepeat(stmt)
+	case *ast.ReturnStatement:
+		e.resolveReturn(stmt)
+	case *ast.WhileStatement:
+		e.resolveWhile(stmt)
 	default:
-		e.addError(stmt, "Unimplemented")
+		e.addError(unimplemented, stmt, "Unimplemented")
 	}
 }
 
@@ -184,7 +289,7 @@ func (e *Environment) resolveExprType(expr ast.Expression) Type {
 	case *ast.BooleanLiteral:
 		return e.addType(expr, &Boolean{})
 	case *ast.NilLiteral:
-		return e.addType(expr, &Unknown{})
+		return e.addType(expr, &Nil{})
 	case *ast.NumberLiteral:
 		return e.addType(expr, &Number{})
 	case *ast.StringLiteral:
@@ -192,145 +297,131 @@ func (e *Environment) resolveExprType(expr ast.Expression) Type {
 
 	case *ast.Identifier:
 		def := e.FindDefinition(ast.NodePath{Node: expr})
+		e.checkDeprecated(expr, def)
 		if def != nil {
-			typ := e.Types[def]
+			typ := e.varType(def)
 			if typ != nil {
 				return e.addType(expr, typ)
 			}
+		} else if typ := e.globalType(expr.Token.Literal); typ != nil {
+			return e.addType(expr, typ)
 		} else {
-			e.Errors = append(e.Errors, ast.Error{Message: fmt.Sprintf("Unknown variable '%s'", expr.Token.Literal), Range: ast.Range(expr)})
+			e.addError(undefinedGlobal, expr, "Undefined global '%s'", expr.Token.Literal)
 		}
 
 	case *ast.FunctionExpression:
-		typ := &Function{Params: []NameAndType{}}
-		for _, param := range expr.Params.Pairs {
-			// TODO: Function parameter types - requires parsing doc comments
-			typ.Params = append(typ.Params, NameAndType{Name: param.Node.Token.Literal, Type: &Unknown{}})
+		// `local f = function() end` is documented by its statement
+		var comment *doc.Comment
+		if len(e.Nodes) > 1 {
+			if stmt, ok := e.Nodes[len(e.Nodes)-2].(ast.Statement); ok {
+				comment = e.Docs[stmt]
+			}
 		}
-		return e.addType(expr, typ)
-	case *ast.FunctionCall:
-		return e.resolveFunctionCallType(expr)
+		return e.addType(expr, e.resolveFunctionType(comment, nil, &expr.Params, expr.Vararg, &expr.Body))
+	case *ast.FunctionCall, *ast.Vararg:
+		// Only the first value is used
+		return e.addType(expr, e.resolveExprValues(expr).At(0))
+	case *ast.InfixExpression:
+		return e.resolveInfixType(expr)
+	case *ast.PrefixExpression:
+		return e.resolvePrefixType(expr)
 	case *ast.IndexExpression:
 		leftTyp := e.resolveExprType(expr.Prefix)
 		if leftTyp == nil {
 			return e.addType(expr, &Unknown{})
 		}
+		if canBeNil(leftTyp) {
+			leftTyp = truthy(leftTyp)
+			if leftTyp == nil {
+				e.addError(indexNil, expr.Prefix, "Attempting to index a nil value")
+				return nil
+			}
+			e.addError(indexNil, expr.Prefix, "Attempting to index a value that may be nil")
+		}
+		if union, ok := leftTyp.(*Union); ok {
+			return e.resolveUnionIndexType(expr, union)
+		}
+		switch leftTyp.(type) {
+		case *Any, *Unknown:
+			return e.addType(expr, &Unknown{})
+		case *String:
+			leftTyp = e.stringLibrary(leftTyp)
+		}
 		tbl, ok := leftTyp.(*Table)
 		if !ok {
-			e.addError(expr.Inner, "Attempting to index a non-table")
+			e.addError(indexNonTable, expr.Inner, "Attempting to index a non-table")
 			return nil
 		}
 
-		// e.resolveExprType(expr.Inner)
-
-		var key string
-		switch inner := expr.Inner.(type) {
-		case *ast.Identifier:
-			key = inner.Token.Literal
-		case *ast.StringLiteral:
-			key = inner.Token.Literal
-		default:
-			e.addError(inner, "Unimplemented")
-			return nil
+		key, ok := indexName(expr)
+		if !ok {
+			keyTyp := typeOrUnknown(e.resolveExprType(expr.Inner))
+			if tbl.Value == nil {
+				// TODO: Report indexing records with unknown keys
+				return e.addType(expr, &Unknown{})
+			}
+			if !IsAssignable(keyTyp, tbl.Key) {
+				e.addError(indexTypeMismatch, expr.Inner, "Cannot index '%s' with '%s'", tbl, keyTyp)
+			}
+			return e.addType(expr, tbl.Value)
 		}
 
-		for i := 0; i < len(tbl.Fields); i++ {
-			if tbl.Fields[i].Name == key {
-				typ := tbl.Fields[i].Type
-				e.addType(expr, typ)
-				e.addType(expr.Inner, typ)
-				return typ
-			}
+		if field := tbl.field(key); field != nil {
+			e.addType(expr, field.Type)
+			e.addType(expr.Inner, field.Type)
+			return field.Type
 		}
 
-		for i := len(e.Nodes) - 1; i >= 0; i-- {
-			switch node := e.Nodes[i].(type) {
-			case *ast.AssignmentStatement:
-				for j, leftVar := range node.Vars.Pairs {
-					if leftVar.Node == expr {
-						tbl.Fields = append(tbl.Fields, NameAndType{
-							Name: key,
-							Def:  node.Exps.Pairs[j].Node, // TODO: Out of bounds checking
-							Type: e.Types[node.Exps.Pairs[j].Node],
-						})
-						e.addType(expr, e.Types[node.Exps.Pairs[j].Node])
-						e.addType(expr.Inner, e.Types[node.Exps.Pairs[j].Node])
-						return nil
-					}
+		// Assigning to a missing field creates it. An inherited field is
+		// shadowed, and the assigned type is checked against it.
+		inherited := tbl.lookup(key)
+		switch node := e.Nodes[len(e.Nodes)-2].(type) {
+		case *ast.AssignmentStatement:
+			for j, leftVar := range node.Vars.Pairs {
+				if leftVar.Node != expr {
+					continue
+				}
+				// The assigned type was added by the statement
+				var def ast.Node = node
+				if j < len(node.Exps.Pairs) {
+					def = node.Exps.Pairs[j].Node
 				}
-			case *ast.FunctionStatement:
-				tbl.Fields = append(tbl.Fields, NameAndType{
-					Name: key,
-					Def:  node,
-					Type: e.Types[node],
-				})
+				tbl.setField(key, def, e.Types[expr])
+				e.addType(expr.Inner, e.Types[expr])
+				if inherited != nil {
+					return inherited.Type
+				}
+				return nil
+			}
+		case *ast.FunctionStatement:
+			if node.Name == expr {
+				tbl.setField(key, node, e.Types[node])
 				e.addType(expr, e.Types[node])
 				e.addType(expr.Inner, e.Types[node])
 				return nil
-
 			}
 		}
 
-		e.addError(expr.Inner, "Unknown field '%s'", key)
-	// TODO:
-	// case *ast.TableLiteral:
-	// 	typ := Table{
-	// 		Fields: []NameAndType{},
-	// 	}
-	// 	for _, fieldNode := range expr.Fields.Pairs {
-	// 		ident, ok := fieldNode.Node.Key.(*ast.Identifier)
-	// 		if !ok {
-	// 			// TODO:
-	// 			continue
-	// 		}
-	// 		valueTyp := e.resolveExprType(fieldNode.Node.Value)
-	// 		field := NameAndType{
-	// 			Name: ident.Token.Literal,
-	// 			Type: valueTyp,
-	// 		}
-	// 		typ.Fields = append(typ.Fields, field)
-	// 		e.addType(fieldNode.Node, valueTyp)
-	// 		e.addType(ident, valueTyp)
-	// 	}
-	// 	e.addType(expr, &typ)
-	// 	return &typ
-	default:
-		e.addError(expr, "Unimplemented")
-	}
-
-	return nil
-}
-
-func (e *Environment) resolveFunctionCallType(fc *ast.FunctionCall) Type {
-	typ := e.resolveExprType(fc.Name)
-	if typ == nil {
-		return nil
-	}
-	e.addType(fc, typ)
-	function, ok := typ.(*Function)
-	if !ok {
-		e.addError(fc, "'%s' is not a function", fc.Name)
-		return typ
-	}
-	for i := 0; i < len(fc.Args.Pairs); i++ {
-		arg := fc.Args.Pairs[i]
-		argTyp := e.resolveExprType(arg.Node)
-		if i >= len(function.Params) {
-			e.addError(arg.Node, "Unused parameter")
-			break // TODO:
+		if inherited != nil {
+			e.addType(expr, inherited.Type)
+			e.addType(expr.Inner, inherited.Type)
+			return inherited.Type
 		}
-		if argTyp == nil {
-			continue // TODO:
+		if tbl.Value != nil && IsAssignable(&String{}, tbl.Key) {
+			return e.addType(expr, tbl.Value)
 		}
-		if argTyp != function.Params[i].Type {
-			e.addError(arg.Node, "Cannot use '%s' as '%s' in argument.", argTyp, function.Params[i].Type)
+		if e.isAssignedLater(tbl, key) {
+			e.addType(expr.Inner, &Unknown{})
+			return e.addType(expr, &Unknown{})
 		}
-		e.addType(arg.Node, argTyp)
-	}
-	if len(fc.Args.Pairs) < len(function.Params) {
-		e.addError(fc, "Too few function parameters, expected %v, got %v", len(function.Params), len(fc.Args.Pairs))
+		e.addError(undefinedField, expr.Inner, "Unknown field '%s'", key)
+	case *ast.TableLiteral:
+		return e.addType(expr, e.resolveTableType(expr))
+	default:
+		e.addError(unimplemented, expr, "Unimplemented")
 	}
-	return function.Return
+
+	return nil
 }
 
 func (e *Environment) addType(node ast.Node, typ Type) Type {
@@ -338,79 +429,18 @@ func (e *Environment) addType(node ast.Node, typ Type) Type {
 	return typ
 }
 
+// FindDefinition returns the declaration of the local variable at the end of
+// the path, or nil if it is not a local variable.
 func (e *Environment) FindDefinition(path ast.NodePath) *ast.Identifier {
-	if path.Node == nil {
+	ident, ok := path.Node.(*ast.Identifier)
+	if !ok {
 		return nil
 	}
-	identFor, ok := path.Node.(*ast.Identifier)
-	if !ok {
+	binding := e.Scopes.Uses[ident]
+	if binding == nil {
 		return nil
 	}
-
-	pos := identFor.Pos()
-	var def *ast.Identifier
-	ast.Walk(&e.file.Block, func(node ast.Node) bool {
-		isAfter := node.Pos() > pos && pos < node.End()
-		if isAfter {
-			return false
-		}
-		isInside := node.Pos() <= pos && pos < node.End()
-		switch node := node.(type) {
-		case *ast.ForInStatement:
-			if isInside {
-				for _, ident := range node.Names.Pairs {
-					if ident.Node.Token.Literal == identFor.Token.Literal {
-						def = ident.Node
-					}
-				}
-			}
-		case *ast.ForStatement:
-			if isInside {
-				if node.Name != nil && node.Name.Token.Literal == identFor.Token.Literal {
-					def = node.Name
-				}
-			}
-		case *ast.FunctionExpression:
-			if isInside {
-				for _, ident := range node.Params.Pairs {
-					if ident.Node.Token.Literal == identFor.Token.Literal {
-						def = ident.Node
-					}
-				}
-			}
-		case *ast.FunctionStatement:
-			if isInside {
-				for _, ident := range node.Params.Pairs {
-					if ident.Node.Token.Literal == identFor.Token.Literal {
-						def = ident.Node
-					}
-				}
-			}
-			if ident, ok := node.Name.(*ast.Identifier); ok {
-				if ident.Token.Literal == identFor.Token.Literal {
-					def = ident
-				}
-			}
-		case *ast.LocalStatement:
-			for _, ident := range node.Names.Pairs {
-				if ident.Node.Token.Literal == identFor.Token.Literal {
-					def = ident.Node
-				}
-			}
-		default:
-			return isInside
-		}
-
-		return true
-	})
-	return def
-}
-
-func (e *Environment) addError(node ast.Node, messageFmt string, messageArgs ...any) {
-	e.Errors = append(e.Errors, ast.Error{
-		Message: fmt.Sprintf(messageFmt, messageArgs...),
-		Range:   ast.Range(node),
-	})
+	return binding.Ident
 }
 
 func (e *Environment) pushNode(node ast.Node) {
@@ -0,0 +1,354 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/doc"
+)
+
+// resolveTypeParams creates the type parameters of a @generic annotation or
+// a generic @class. They can be used by name until popTypeParams is called.
+func (e *Environment) resolveTypeParams(params []*doc.GenericParam) []*TypeParam {
+	scope := map[string]*TypeParam{}
+	e.typeParams = append(e.typeParams, scope)
+	typeParams := []*TypeParam{}
+	constraints := []doc.Type{}
+	for _, param := range params {
+		if param.Name == nil {
+			continue
+		}
+		typeParam := &TypeParam{Name: param.Name.Name}
+		scope[typeParam.Name] = typeParam
+		typeParams = append(typeParams, typeParam)
+		constraints = append(constraints, param.Constraint)
+	}
+	// Constraints can refer to other type parameters
+	for i, constraint := range constraints {
+		if constraint != nil {
+			typeParams[i].Constraint = e.resolveDocType(constraint)
+		}
+	}
+	return typeParams
+}
+
+// pushTypeParams makes existing type parameters usable by name.
+func (e *Environment) pushTypeParams(params []*TypeParam) {
+	scope := map[string]*TypeParam{}
+	for _, param := range params {
+		scope[param.Name] = param
+	}
+	e.typeParams = append(e.typeParams, scope)
+}
+
+func (e *Environment) popTypeParams() {
+	e.typeParams = e.typeParams[:len(e.typeParams)-1]
+}
+
+// findTypeParam returns the type parameter with the given name that is in
+// scope, or nil if there is none.
+func (e *Environment) findTypeParam(name string) *TypeParam {
+	for i := len(e.typeParams) - 1; i >= 0; i-- {
+		if param := e.typeParams[i][name]; param != nil {
+			return param
+		}
+	}
+	return nil
+}
+
+// instantiate returns an instance of a generic class with the given type
+// arguments. Missing arguments are unknown.
+func instantiate(class *Table, args []Type) *Table {
+	typeArgs := make([]Type, len(class.TypeParams))
+	for i := range typeArgs {
+		if i < len(args) {
+			typeArgs[i] = args[i]
+		} else {
+			typeArgs[i] = &Unknown{}
+		}
+	}
+	return &Table{Name: class.Name, Generic: class, TypeArgs: typeArgs}
+}
+
+// typeBindings returns the type arguments of an instance by type parameter.
+func (t *Table) typeBindings() map[*TypeParam]Type {
+	bindings := map[*TypeParam]Type{}
+	if t.Generic == nil {
+		return bindings
+	}
+	for i, param := range t.Generic.TypeParams {
+		if i < len(t.TypeArgs) {
+			bindings[param] = t.TypeArgs[i]
+		}
+	}
+	return bindings
+}
+
+// sameInstance returns whether a and b are instances of the same class with
+// equal type arguments.
+func sameInstance(a, b *Table, seen map[[2]Type]bool) bool {
+	if a.Generic == nil || a.Generic != b.Generic || len(a.TypeArgs) != len(b.TypeArgs) {
+		return false
+	}
+	for i := range a.TypeArgs {
+		if !equal(a.TypeArgs[i], b.TypeArgs[i], seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// instantiateFunction infers the type arguments of a call to a generic
+// function from the types of its arguments, and returns the function with
+// them substituted. Type parameters that cannot be inferred are unknown.
+func instantiateFunction(fn *Function, args *Tuple) *Function {
+	bindings := map[*TypeParam]Type{}
+	for _, param := range fn.TypeParams {
+		bindings[param] = nil
+	}
+	seen := map[[2]Type]bool{}
+	for i, param := range fn.Params {
+		inferTypeArgs(typeOrUnknown(param.Type), args.At(i), bindings, seen)
+	}
+	if fn.Vararg != nil {
+		for i := len(fn.Params); i < len(args.Types); i++ {
+			inferTypeArgs(fn.Vararg, args.Types[i], bindings, seen)
+		}
+		if args.Rest != nil {
+			inferTypeArgs(fn.Vararg, args.Rest, bindings, seen)
+		}
+	}
+	for param, typ := range bindings {
+		if typ == nil {
+			bindings[param] = &Unknown{}
+		}
+	}
+	return substitute(fn, bindings).(*Function)
+}
+
+// inferTypeArgs binds the type parameters in param to the parts of arg that
+// are in their place.
+func inferTypeArgs(param, arg Type, bindings map[*TypeParam]Type, seen map[[2]Type]bool) {
+	if _, ok := arg.(*Unknown); ok {
+		// Nothing is known
+		return
+	}
+	key := [2]Type{param, arg}
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	switch param := param.(type) {
+	case *TypeParam:
+		if bound, ok := bindings[param]; ok {
+			bindings[param] = NewUnion(bound, arg)
+		}
+	case *Union:
+		// `T|nil` binds T to the rest of the argument
+		var typeParam *TypeParam
+		others := []Type{}
+		for _, member := range param.Types {
+			if tp, ok := member.(*TypeParam); ok && typeParam == nil {
+				if _, ok := bindings[tp]; ok {
+					typeParam = tp
+					continue
+				}
+			}
+			others = append(others, member)
+		}
+		if typeParam == nil {
+			return
+		}
+		rest := []Type{}
+		for _, member := range Members(arg) {
+			if len(others) == 0 || !isAssignable(member, NewUnion(others...), map[[2]Type]bool{}) {
+				rest = append(rest, member)
+			}
+		}
+		if len(rest) > 0 {
+			inferTypeArgs(typeParam, NewUnion(rest...), bindings, seen)
+		}
+	case *Table:
+		arg, ok := arg.(*Table)
+		if !ok {
+			return
+		}
+		if param.Generic != nil && arg.Generic == param.Generic {
+			for i := range param.TypeArgs {
+				if i < len(arg.TypeArgs) {
+					inferTypeArgs(param.TypeArgs[i], arg.TypeArgs[i], bindings, seen)
+				}
+			}
+			return
+		}
+		if param.Value != nil && arg.Value != nil {
+			inferTypeArgs(param.Key, arg.Key, bindings, seen)
+			inferTypeArgs(param.Value, arg.Value, bindings, seen)
+		}
+		if param.Value != nil && len(arg.Fields) > 0 {
+			// The fields of a record have string keys
+			values := make([]Type, len(arg.Fields))
+			for i, field := range arg.Fields {
+				values[i] = typeOrUnknown(field.Type)
+			}
+			inferTypeArgs(param.Key, &String{}, bindings, seen)
+			inferTypeArgs(param.Value, NewUnion(values...), bindings, seen)
+		}
+		for _, field := range param.Fields {
+			if argField := arg.lookup(field.Name); argField != nil {
+				inferTypeArgs(typeOrUnknown(field.Type), typeOrUnknown(argField.Type), bindings, seen)
+			}
+		}
+	case *Function:
+		arg, ok := arg.(*Function)
+		if !ok {
+			return
+		}
+		for i := range param.Params {
+			if i < len(arg.Params) {
+				inferTypeArgs(typeOrUnknown(param.Params[i].Type), typeOrUnknown(arg.Params[i].Type), bindings, seen)
+			}
+		}
+		if param.Returns != nil && arg.Returns != nil {
+			for i := range param.Returns.Types {
+				inferTypeArgs(param.Returns.Types[i], arg.Returns.At(i), bindings, seen)
+			}
+		}
+	}
+}
+
+// substitute returns typ with the given type parameters replaced. Classes
+// are not copied, but instances of generic classes are.
+func substitute(typ Type, bindings map[*TypeParam]Type) Type {
+	return substituteSeen(typ, bindings, map[*Table]*Table{})
+}
+
+func substituteSeen(typ Type, bindings map[*TypeParam]Type, seen map[*Table]*Table) Type {
+	if len(bindings) == 0 {
+		return typ
+	}
+	switch typ := typ.(type) {
+	case *TypeParam:
+		if bound, ok := bindings[typ]; ok && bound != nil {
+			return bound
+		}
+	case *Union:
+		members := make([]Type, len(typ.Types))
+		for i, member := range typ.Types {
+			members[i] = substituteSeen(member, bindings, seen)
+		}
+		return NewUnion(members...)
+	case *Function:
+		fn := &Function{Params: make([]NameAndType, len(typ.Params))}
+		for _, param := range typ.TypeParams {
+			if _, ok := bindings[param]; !ok {
+				fn.TypeParams = append(fn.TypeParams, param)
+			}
+		}
+		for i, param := range typ.Params {
+			fn.Params[i] = NameAndType{Name: param.Name, Def: param.Def, Type: substituteSeen(typeOrUnknown(param.Type), bindings, seen)}
+		}
+		if typ.Vararg != nil {
+			fn.Vararg = substituteSeen(typ.Vararg, bindings, seen)
+		}
+		if typ.Returns != nil {
+			fn.Returns = substituteTuple(typ.Returns, bindings, seen)
+		}
+		return fn
+	case *Table:
+		if typ.Generic != nil {
+			args := make([]Type, len(typ.TypeArgs))
+			for i, arg := range typ.TypeArgs {
+				args[i] = substituteSeen(arg, bindings, seen)
+			}
+			return instantiate(typ.Generic, args)
+		}
+		if typ.Name != "" {
+			return typ
+		}
+		if tbl := seen[typ]; tbl != nil {
+			return tbl
+		}
+		tbl := &Table{Parents: typ.Parents, Metatable: typ.Metatable}
+		seen[typ] = tbl
+		for _, field := range typ.Fields {
+			tbl.Fields = append(tbl.Fields, NameAndType{Name: field.Name, Def: field.Def, Type: substituteSeen(typeOrUnknown(field.Type), bindings, seen)})
+		}
+		if typ.Value != nil {
+			tbl.Key = substituteSeen(typ.Key, bindings, seen)
+			tbl.Value = substituteSeen(typ.Value, bindings, seen)
+		}
+		return tbl
+	}
+	return typ
+}
+
+func substituteTuple(tuple *Tuple, bindings map[*TypeParam]Type, seen map[*Table]*Table) *Tuple {
+	result := &Tuple{Types: make([]Type, len(tuple.Types))}
+	for i, typ := range tuple.Types {
+		result.Types[i] = substituteSeen(typ, bindings, seen)
+	}
+	if tuple.Rest != nil {
+		result.Rest = substituteSeen(tuple.Rest, bindings, seen)
+	}
+	return result
+}
+
+// copyType returns a copy of typ whose tables can be changed without changing
+// the original, for types that come from another file. Classes are not
+// copied.
+func copyType(typ Type) Type {
+	return copySeen(typ, map[*Table]*Table{})
+}
+
+func copySeen(typ Type, seen map[*Table]*Table) Type {
+	switch typ := typ.(type) {
+	case *Union:
+		members := make([]Type, len(typ.Types))
+		for i, member := range typ.Types {
+			members[i] = copySeen(member, seen)
+		}
+		return &Union{Types: members}
+	case *Function:
+		fn := &Function{TypeParams: typ.TypeParams, Params: make([]NameAndType, len(typ.Params))}
+		for i, param := range typ.Params {
+			fn.Params[i] = NameAndType{Name: param.Name, Def: param.Def, Type: copySeen(param.Type, seen)}
+		}
+		if typ.Vararg != nil {
+			fn.Vararg = copySeen(typ.Vararg, seen)
+		}
+		if typ.Returns != nil {
+			fn.Returns = copyTuple(typ.Returns, seen)
+		}
+		return fn
+	case *Table:
+		if typ.Name != "" || typ.Generic != nil {
+			return typ
+		}
+		if tbl := seen[typ]; tbl != nil {
+			return tbl
+		}
+		tbl := &Table{Parents: typ.Parents}
+		seen[typ] = tbl
+		for _, field := range typ.Fields {
+			tbl.Fields = append(tbl.Fields, NameAndType{Name: field.Name, Def: field.Def, Type: copySeen(field.Type, seen)})
+		}
+		if typ.Value != nil {
+			tbl.Key = copySeen(typ.Key, seen)
+			tbl.Value = copySeen(typ.Value, seen)
+		}
+		if typ.Metatable != nil {
+			tbl.Metatable = copySeen(typ.Metatable, seen).(*Table)
+		}
+		return tbl
+	}
+	return typ
+}
+
+func copyTuple(tuple *Tuple, seen map[*Table]*Table) *Tuple {
+	result := &Tuple{Types: make([]Type, len(tuple.Types))}
+	for i, typ := range tuple.Types {
+		result.Types[i] = copySeen(typ, seen)
+	}
+	if tuple.Rest != nil {
+		result.Rest = copySeen(tuple.Rest, seen)
+	}
+	return result
+}
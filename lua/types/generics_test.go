@@ -0,0 +1,113 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenericFunctions(t *testing.T) {
+	src := `---@generic T
+---@param x T
+---@return T
+local function identity(x)
+  local inner = x
+  return x
+end
+
+---@generic T, U
+---@param list T[]
+---@param fn fun(value: T): U
+---@return U[]
+local function map(list, fn)
+  return {}
+end
+
+---@generic T
+---@param value T?
+---@param default T
+---@return T
+local function orDefault(value, default)
+  return value or default
+end
+
+local a = identity(1)
+local b = identity("s")
+local c = map({1, 2}, function(n) return "x" end)
+---@type string?
+local maybe
+local d = orDefault(maybe, "")
+local e = identity()
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "function<T>(x: T) → T", typeAt("identity(x)"))
+	assert.Equal(t, "T", typeAt("inner ="))
+	assert.Equal(t, "number", typeAt("a ="))
+	assert.Equal(t, "string", typeAt("b ="))
+	assert.Equal(t, "string[]", typeAt("c ="))
+	assert.Equal(t, "string", typeAt("d ="))
+	// A missing argument is nil
+	assert.Equal(t, "nil", typeAt("e ="))
+	// The call shows the instantiated signature
+	assert.Equal(t, "function(x: number) → number", typeAt("identity(1)"))
+	assert.Equal(t, "function(list: number[], fn: function(value: number) → string) → string[]", typeAt("map({"))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestGenericClasses(t *testing.T) {
+	src := `---@class Queue<T>
+---@field items T[]
+local Queue = {}
+Queue.__index = Queue
+
+---@param item T
+function Queue:push(item)
+  local items = self.items
+end
+
+---@return T
+function Queue:pop()
+  return self.items[1]
+end
+
+---@type Queue<number>
+local q
+local items = q.items
+local popped = q:pop()
+q:push(1)
+q:push("a")
+
+---@type Queue<string>
+local other = q
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "Queue<T>", typeAt("Queue = {}"))
+	assert.Equal(t, "T[]", typeAt("items = self"))
+	assert.Equal(t, "Queue<number>", typeAt("q\n"))
+	assert.Equal(t, "number[]", typeAt("items = q"))
+	assert.Equal(t, "number", typeAt("popped ="))
+
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message)
+	}
+	assert.Equal(t, []string{
+		"Cannot use 'string' as 'number' in argument.",
+		"Cannot assign 'Queue<number>' to 'Queue<string>'",
+	}, errors)
+}
@@ -0,0 +1,145 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/raiguard/luapls/lua/ast"
+)
+
+// findTopLevelGlobals finds the global variables that are assigned outside of
+// functions. Functions can use them before the assignment is resolved.
+func (e *Environment) findTopLevelGlobals() {
+	e.topLevelGlobals = map[string]bool{}
+	for _, pair := range e.file.Block.Pairs {
+		var names []ast.Expression
+		switch stmt := pair.Node.(type) {
+		case *ast.AssignmentStatement:
+			for _, pair := range stmt.Vars.Pairs {
+				names = append(names, pair.Node)
+			}
+		case *ast.FunctionStatement:
+			if stmt.LocalTok == nil {
+				names = append(names, stmt.Name)
+			}
+		}
+		for _, name := range names {
+			if ident, ok := name.(*ast.Identifier); ok && e.Scopes.Uses[ident] == nil {
+				e.topLevelGlobals[ident.Token.Literal] = true
+			}
+		}
+	}
+}
+
+// globalType returns the type of the global variable, or nil if it is not
+// defined by this file, another file or the standard library.
+func (e *Environment) globalType(name string) Type {
+	if typ := e.GlobalTypes[name]; typ != nil {
+		return typ
+	}
+	if typ := e.importedGlobals[name]; typ != nil {
+		return typ
+	}
+	if e.GlobalType != nil {
+		if typ := e.GlobalType(name); typ != nil {
+			// Assignments in this file must not change the other file's global
+			typ = copyType(typ)
+			e.importedGlobals[name] = typ
+			return typ
+		}
+	}
+	if e.topLevelGlobals[name] {
+		// Assigned later in the file
+		return &Unknown{}
+	}
+	return nil
+}
+
+// assignGlobal checks a value assigned to a global variable. The first
+// assignment defines its type.
+func (e *Environment) assignGlobal(ident *ast.Identifier, typ Type) {
+	name := ident.Token.Literal
+	declared := e.GlobalTypes[name]
+	switch declared.(type) {
+	case nil:
+		e.checkGlobalWrite(ident)
+		e.GlobalTypes[name] = typ
+	case *Nil:
+		// `x = nil` declares a variable that is assigned later
+		e.GlobalTypes[name] = NewOptional(typ)
+	default:
+		if !IsAssignable(typ, declared) {
+			e.addError(assignTypeMismatch, ident, "Cannot assign '%s' to '%s'", typ, declared)
+			e.addType(ident, declared)
+			return
+		}
+	}
+	e.addType(ident, typ)
+}
+
+// checkGlobalWrite reports an assignment that creates a global variable by
+// accident, because the name of a local was mistyped or because it is in a
+// function.
+func (e *Environment) checkGlobalWrite(ident *ast.Identifier) {
+	name := ident.Token.Literal
+	if e.GlobalType != nil && e.GlobalType(name) != nil {
+		return
+	}
+	var similar *Binding
+	distance := 0
+	for localName, binding := range e.Scopes.Visible(ident.Pos()) {
+		d := editDistance(name, localName)
+		if d == 0 || d > typoDistance(name) {
+			continue
+		}
+		if similar == nil || d < distance || (d == distance && localName < similar.Name) {
+			similar, distance = binding, d
+		}
+	}
+	if similar != nil {
+		err := e.addError(globalWrite, ident, "Assignment to undefined global '%s', did you mean the local '%s'?", name, similar.Name)
+		err.Related = []ast.RelatedInfo{{Message: fmt.Sprintf("'%s' is declared here", similar.Name), Range: ast.Range(similar.Ident)}}
+		return
+	}
+	if len(e.functions) > 0 && !e.topLevelGlobals[name] {
+		e.addError(globalWrite, ident, "Assignment to undefined global '%s' in a function", name)
+	}
+}
+
+// typoDistance returns the largest edit distance from name that is considered
+// a typo. Short names are too similar to each other.
+func typoDistance(name string) int {
+	switch {
+	case len(name) < 3:
+		return 0
+	case len(name) < 8:
+		return 1
+	}
+	return 2
+}
+
+// editDistance returns the number of single byte insertions, deletions,
+// substitutions and transpositions of adjacent bytes that turn a into b.
+func editDistance(a, b string) int {
+	// The rows of the distances between the prefixes of a and b
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
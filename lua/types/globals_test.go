@@ -0,0 +1,53 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobals(t *testing.T) {
+	src := `local function setup()
+  config = {}
+  counter = 0
+  helper()
+end
+local function helper() end
+counter = 1
+local count = 0
+conut = 1
+countr = 2
+x = 1
+print(config, missing, shared, counter)
+`
+	file := parser.New(src).ParseFile()
+	env := NewEnvironment(&file)
+	env.GlobalType = func(name string) Type {
+		if name == "shared" {
+			return &String{}
+		}
+		return nil
+	}
+	env.ResolveTypes()
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Code+": "+err.Message)
+	}
+	assert.Equal(t, []string{
+		"global-write: Assignment to undefined global 'config' in a function",
+		"undefined-global: Undefined global 'helper'",
+		"global-write: Assignment to undefined global 'conut', did you mean the local 'count'?",
+		"global-write: Assignment to undefined global 'countr', did you mean the local 'count'?",
+		"undefined-global: Undefined global 'missing'",
+	}, errors)
+	assert.Equal(t, "string", env.Types[identAt(t, &env, len(src)-len("shared, counter)\n"))].String())
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("count", "count"))
+	assert.Equal(t, 1, editDistance("count", "conut"))
+	assert.Equal(t, 1, editDistance("count", "counts"))
+	assert.Equal(t, 2, editDistance("kitten", "sittin"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
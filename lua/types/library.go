@@ -0,0 +1,61 @@
+package types
+
+import (
+	"embed"
+	"strings"
+	"sync"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// The standard library is described by annotated Lua files. Every dialect
+// loads the base definitions and those of the versions that it includes.
+//
+//go:embed stdlib/*.lua
+var stdlib embed.FS
+
+var libraryFiles = map[token.Dialect][]string{
+	token.Lua51:  {"base.lua", "lua51.lua"},
+	token.Lua52:  {"base.lua", "lua52.lua", "bit32.lua"},
+	token.Lua53:  {"base.lua", "lua52.lua", "lua53.lua"},
+	token.Lua54:  {"base.lua", "lua52.lua", "lua53.lua", "lua54.lua"},
+	token.LuaJIT: {"base.lua", "lua51.lua", "luajit.lua"},
+}
+
+var (
+	libraries   = map[token.Dialect]*parser.File{}
+	librariesMu sync.Mutex
+)
+
+// library returns the parsed standard library definitions for the dialect.
+// They are parsed once and shared, so they must not be modified.
+func library(dialect token.Dialect) *parser.File {
+	librariesMu.Lock()
+	defer librariesMu.Unlock()
+	if file := libraries[dialect]; file != nil {
+		return file
+	}
+	var src strings.Builder
+	for _, name := range libraryFiles[dialect] {
+		contents, err := stdlib.ReadFile("stdlib/" + name)
+		if err != nil {
+			panic(err)
+		}
+		src.Write(contents)
+		src.WriteByte('\n')
+	}
+	file := parser.NewWithDialect(src.String(), dialect).ParseFile()
+	libraries[dialect] = &file
+	return &file
+}
+
+// resolveLibrary returns the environment of the standard library for the
+// dialect. Its types are created fresh, so that the environment that uses
+// them can modify them.
+func resolveLibrary(dialect token.Dialect) *Environment {
+	env := NewEnvironment(library(dialect))
+	env.isLibrary = true
+	env.ResolveTypes()
+	return &env
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryDefinitions(t *testing.T) {
+	for dialect := range libraryFiles {
+		t.Run(dialect.String(), func(t *testing.T) {
+			require.Empty(t, library(dialect).Errors)
+			env := resolveLibrary(dialect)
+			assert.Empty(t, withoutUnused(env.Errors))
+			assert.IsType(t, &Function{}, env.GlobalTypes["print"])
+		})
+	}
+}
+
+func TestLibraryDialects(t *testing.T) {
+	tests := []struct {
+		dialect token.Dialect
+		global  string
+		defined bool
+	}{
+		{token.Lua51, "setfenv", true},
+		{token.Lua54, "setfenv", false},
+		{token.Lua52, "bit32", true},
+		{token.Lua53, "utf8", true},
+		{token.Lua53, "bit32", false},
+		{token.Lua54, "warn", true},
+		{token.LuaJIT, "bit", true},
+		{token.LuaJIT, "unpack", true},
+	}
+	for _, test := range tests {
+		file := parser.NewWithDialect("", test.dialect).ParseFile()
+		env := NewEnvironment(&file)
+		env.ResolveTypes()
+		_, ok := env.GlobalTypes[test.global]
+		assert.Equal(t, test.defined, ok, "%s in %s", test.global, test.dialect)
+	}
+}
+
+func TestLibraryTypes(t *testing.T) {
+	src := `local s = string.format("%d", 1)
+local n = math.floor(1.5)
+local upper = ("a"):upper()
+local list = {"a", "b"}
+table.insert(list, "c")
+math.floor("1")
+for i, v in ipairs(list) do
+  local index, value = i, v
+end
+for k, v in pairs({a = 1, b = 2}) do
+  local key, val = k, v
+end
+for k, v in next, {true} do
+  local nextValue = v
+end
+---@type string?
+local maybe
+local sure = assert(maybe)
+print(s, n, upper)
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "string", typeAt("s ="))
+	assert.Equal(t, "number", typeAt("n ="))
+	assert.Equal(t, "string", typeAt("upper ="))
+	assert.Equal(t, "number", typeAt("index,"))
+	assert.Equal(t, "string", typeAt("value ="))
+	assert.Equal(t, "string", typeAt("key,"))
+	assert.Equal(t, "number", typeAt("val ="))
+	assert.Equal(t, "boolean", typeAt("nextValue ="))
+	assert.Equal(t, "string", typeAt("sure ="))
+
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message+": "+src[err.Range.Start:err.Range.End])
+	}
+	assert.Equal(t, []string{`Cannot use 'string' as 'number' in argument.: "1"`}, errors)
+}
+
+func TestPredefinedGlobals(t *testing.T) {
+	src := "print(game.tick, script, undefined)"
+	file := parser.New(src).ParseFile()
+	env := NewEnvironment(&file)
+	env.Options.Globals = []string{"game", "script", "print"}
+	env.ResolveTypes()
+	assert.Equal(t, "unknown", env.GlobalTypes["game"].String())
+	assert.IsType(t, &Function{}, env.GlobalTypes["print"])
+	errors := withoutUnused(env.Errors)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "Undefined global 'undefined'", errors[0].Message)
+}
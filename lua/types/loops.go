@@ -0,0 +1,141 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+)
+
+// resolveForRange types the variable of a numeric for loop from its start
+// expression, and checks the rest of its range.
+func (e *Environment) resolveForRange(stmt *ast.ForStatement) {
+	typ := e.resolveExprType(stmt.Start.Node)
+	if typ == nil {
+		e.addType(stmt.Name, &Unknown{})
+		return
+	}
+	if !IsAssignable(typ, &Number{}) {
+		e.addError(forRangeTypeMismatch, &stmt.Start, "Range expressions must be of type 'number'")
+		return
+	}
+	e.addType(stmt.Name, typ)
+	finishTyp := e.resolveExprType(stmt.Finish.Node)
+	if finishTyp != nil && !IsAssignable(finishTyp, typ) {
+		e.addError(forRangeTypeMismatch, &stmt.Finish, "Range end must be of type '%s'", typ)
+	}
+	if stmt.Step != nil {
+		stepTyp := e.resolveExprType(stmt.Step.Node)
+		if stepTyp != nil && !IsAssignable(stepTyp, typ) {
+			e.addError(forRangeTypeMismatch, stmt.Step, "Range step must be of type '%s'", typ)
+		}
+	}
+}
+
+// resolveForIn types the variables of a generic for loop, then resolves its
+// body. The expressions yield an iterator function, a state and a control
+// value, and the loop calls the iterator with the state and the control
+// value until its first result is nil. So the variables have the types of its
+// results, and the first one is never nil.
+func (e *Environment) resolveForIn(stmt *ast.ForInStatement) {
+	values := e.resolveExprListValues(&stmt.Exps)
+	results := &Tuple{Rest: &Unknown{}}
+	switch iterator := values.At(0).(type) {
+	case *Function:
+		if len(iterator.TypeParams) > 0 {
+			// `for k, v in next, t`
+			iterator = instantiateFunction(iterator, NewTuple(values.At(1), values.At(2)))
+		}
+		if iterator.Returns != nil {
+			results = iterator.Returns
+		}
+	case *Table:
+		// A table with a __call metamethod
+		call := (*Function)(nil)
+		if iterator.Metatable != nil {
+			if field := iterator.Metatable.field("__call"); field != nil {
+				call, _ = field.Type.(*Function)
+			}
+		}
+		if call == nil {
+			e.addError(notIterable, stmt.Exps.Pairs[0].Node, "Cannot iterate over a '%s' value", iterator)
+		} else if call.Returns != nil {
+			results = call.Returns
+		}
+	case *Any, *Unknown:
+	default:
+		e.addError(notIterable, stmt.Exps.Pairs[0].Node, "Cannot iterate over a '%s' value", iterator)
+	}
+	for i, pair := range stmt.Names.Pairs {
+		typ := results.At(i)
+		if i == 0 {
+			typ = typeOrUnknown(truthy(typ))
+		}
+		e.addType(pair.Node, typ)
+	}
+	e.resolveLoopBody(&stmt.Body, narrowing{})
+}
+
+// resolveWhile resolves a while loop. Its condition is true in the body.
+func (e *Environment) resolveWhile(stmt *ast.WhileStatement) {
+	e.resolveExprType(stmt.Condition)
+	whenTrue, _ := e.narrowCondition(stmt.Condition)
+	e.resolveLoopBody(&stmt.Body, whenTrue)
+}
+
+// resolveRepeat resolves a repeat loop. Its condition can use the locals of
+// its body.
+func (e *Environment) resolveRepeat(stmt *ast.RepeatStatement) {
+	e.pushNarrowing(narrowing{})
+	e.resolveBlockTypes(&stmt.Body)
+	if stmt.Condition != nil {
+		e.resolveExprType(stmt.Condition)
+	}
+	narrowed := e.popNarrowing()
+	e.narrow(e.merge([]narrowing{narrowed, {}}))
+}
+
+// resolveLoopBody resolves the body of a loop with the given narrowing.
+// Variables that are narrowed in the body may or may not be afterwards, since
+// the body may not run.
+func (e *Environment) resolveLoopBody(body *ast.Block, narrowed narrowing) {
+	e.pushNarrowing(narrowed)
+	e.resolveBlockTypes(body)
+	e.narrow(e.merge([]narrowing{e.popNarrowing(), {}}))
+}
+
+// checkBreak reports a break that is not inside of a loop.
+func (e *Environment) checkBreak(stmt *ast.BreakStatement) {
+outer:
+	for i := len(e.Nodes) - 2; i >= 0; i-- {
+		switch e.Nodes[i].(type) {
+		case *ast.ForStatement, *ast.ForInStatement, *ast.RepeatStatement, *ast.WhileStatement:
+			return
+		case *ast.FunctionExpression, *ast.FunctionStatement:
+			break outer
+		}
+	}
+	e.addError(breakOutsideLoop, stmt, "'break' outside of a loop")
+}
+
+// checkGoto reports a goto without a visible label. A label is visible in the
+// block that contains it and the blocks nested in that block, but not in
+// nested functions.
+func (e *Environment) checkGoto(stmt *ast.GotoStatement) {
+	if stmt.Name == nil {
+		return
+	}
+	name := stmt.Name.Token.Literal
+outer:
+	for i := len(e.Nodes) - 2; i >= 0; i-- {
+		switch node := e.Nodes[i].(type) {
+		case *ast.Block:
+			for _, pair := range node.Pairs {
+				if label, ok := pair.Node.(*ast.LabelStatement); ok && label.Name != nil && label.Name.Token.Literal == name {
+					e.labels[label] = true
+					return
+				}
+			}
+		case *ast.FunctionExpression, *ast.FunctionStatement:
+			break outer
+		}
+	}
+	e.addError(undefinedLabel, stmt.Name, "No visible label '%s' for goto", name)
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoops(t *testing.T) {
+	src := `local function range(n)
+  local i = 0
+  return function()
+    i = i + 1
+    if i <= n then
+      return i
+    end
+  end
+end
+for n in range(3) do
+  local fromRange = n
+end
+
+---@param t table<string, number>
+---@return (fun(t: table<string, number>, k?: string): string?, number)
+---@return table<string, number>
+local function iterate(t)
+  return next, t
+end
+for k, v in iterate({}) do
+  local custom = v
+end
+
+for i = 1, 10 do
+  local numeric = i
+end
+for index, value in ipairs({"a"}) do end
+for key, field in pairs({ a = true }) do end
+
+---@type number?
+local x
+while not x do
+  x = 1
+end
+local afterWhile = x
+
+---@type string?
+local y
+repeat
+  local inRepeat = y
+until y
+do
+  local inDo = 1
+end
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "number", typeAt("fromRange ="))
+	assert.Equal(t, "string", typeAt("k, v in iterate"))
+	assert.Equal(t, "number", typeAt("custom ="))
+	assert.Equal(t, "number", typeAt("numeric ="))
+	assert.Equal(t, "number", typeAt("index, value"))
+	assert.Equal(t, "string", typeAt("value in ipairs"))
+	assert.Equal(t, "string", typeAt("key, field"))
+	assert.Equal(t, "boolean", typeAt("field in pairs"))
+	assert.Equal(t, "number?", typeAt("afterWhile ="))
+	assert.Equal(t, "string?", typeAt("inRepeat ="))
+	assert.Equal(t, "number", typeAt("inDo ="))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestLoopErrors(t *testing.T) {
+	src := `for x in 1 do end
+for x in {} do end
+break
+for i = 1, 2 do
+  local function f()
+    break
+  end
+  goto continue
+  goto missing
+  ::continue::
+end
+`
+	env := newTestEnvironment(src)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message+": "+src[err.Range.Start:err.Range.End])
+	}
+	assert.Equal(t, []string{
+		"Cannot iterate over a 'number' value: 1",
+		"Cannot iterate over a '{}' value: {}",
+		"'break' outside of a loop: break",
+		"'break' outside of a loop: break",
+		"No visible label 'missing' for goto: missing",
+	}, errors)
+}
@@ -0,0 +1,279 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// narrowing maps local variables, by definition, to their type at a point in
+// the file. It is more precise than their declared type.
+type narrowing map[*ast.Identifier]Type
+
+// varType returns the type of the local variable at the current position.
+func (e *Environment) varType(def *ast.Identifier) Type {
+	for i := len(e.narrowings) - 1; i >= 0; i-- {
+		if typ, ok := e.narrowings[i][def]; ok {
+			return typ
+		}
+	}
+	return e.Types[def]
+}
+
+func (e *Environment) pushNarrowing(n narrowing) {
+	e.narrowings = append(e.narrowings, n)
+}
+
+func (e *Environment) popNarrowing() narrowing {
+	n := e.narrowings[len(e.narrowings)-1]
+	e.narrowings = e.narrowings[:len(e.narrowings)-1]
+	return n
+}
+
+// narrow sets the types of variables for the rest of the current block.
+func (e *Environment) narrow(n narrowing) {
+	if len(e.narrowings) == 0 {
+		e.pushNarrowing(narrowing{})
+	}
+	current := e.narrowings[len(e.narrowings)-1]
+	for def, typ := range n {
+		current[def] = typ
+	}
+}
+
+// merge returns the narrowing after control flow paths join. A variable that
+// is not narrowed on a path has its current type on that path.
+func (e *Environment) merge(paths []narrowing) narrowing {
+	merged := narrowing{}
+	for _, path := range paths {
+		for def := range path {
+			if _, ok := merged[def]; ok {
+				continue
+			}
+			types := []Type{}
+			for _, other := range paths {
+				if typ, ok := other[def]; ok {
+					types = append(types, typ)
+				} else {
+					types = append(types, e.varType(def))
+				}
+			}
+			merged[def] = NewUnion(types...)
+		}
+	}
+	return merged
+}
+
+// resolveIf resolves an if statement. Each clause is narrowed by its condition
+// and the conditions before it being false. After the statement, variables
+// have the union of their types at the end of every clause that does not
+// return, break or goto.
+func (e *Environment) resolveIf(stmt *ast.IfStatement) {
+	// The conditions of the previous clauses are false
+	previous := narrowing{}
+	e.pushNarrowing(previous)
+	paths := []narrowing{}
+	hasElse := false
+	for _, clause := range stmt.Clauses {
+		whenTrue, whenFalse := narrowing{}, narrowing{}
+		if clause.Condition != nil {
+			e.resolveExprType(clause.Condition)
+			whenTrue, whenFalse = e.narrowCondition(clause.Condition)
+		} else {
+			hasElse = true
+		}
+		e.pushNarrowing(whenTrue)
+		e.resolveBlockTypes(&clause.Body)
+		body := e.popNarrowing()
+		if !blockTerminates(&clause.Body) {
+			path := narrowing{}
+			for def, typ := range previous {
+				path[def] = typ
+			}
+			for def, typ := range body {
+				path[def] = typ
+			}
+			paths = append(paths, path)
+		}
+		for def, typ := range whenFalse {
+			previous[def] = typ
+		}
+	}
+	e.popNarrowing()
+	if !hasElse {
+		paths = append(paths, previous)
+	}
+	e.narrow(e.merge(paths))
+}
+
+// narrowAssert narrows the current block by the condition of an
+// `assert(cond, ...)` call.
+func (e *Environment) narrowAssert(fc *ast.FunctionCall) {
+	name, ok := fc.Name.(*ast.Identifier)
+	if !ok || name.Token.Literal != "assert" || e.Scopes.Uses[name] != nil || len(fc.Args.Pairs) == 0 {
+		return
+	}
+	whenTrue, _ := e.narrowCondition(fc.Args.Pairs[0].Node)
+	e.narrow(whenTrue)
+}
+
+// narrowCondition returns the types of the variables in a condition when it
+// is true and when it is false.
+func (e *Environment) narrowCondition(expr ast.Expression) (whenTrue, whenFalse narrowing) {
+	whenTrue, whenFalse = narrowing{}, narrowing{}
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		def := e.localDef(expr)
+		if def == nil {
+			break
+		}
+		typ := typeOrUnknown(e.varType(def))
+		if t := truthy(typ); t != nil {
+			whenTrue[def] = t
+		}
+		if f := falsy(typ); f != nil {
+			whenFalse[def] = f
+		}
+	case *ast.PrefixExpression:
+		if expr.Operator.Type() == token.NOT {
+			whenFalse, whenTrue = e.narrowCondition(expr.Right)
+		}
+	case *ast.InfixExpression:
+		switch expr.Operator.Type() {
+		case token.AND:
+			// The right side is only evaluated if the left side is true
+			leftTrue, leftFalse := e.narrowCondition(expr.Left)
+			e.pushNarrowing(leftTrue)
+			rightTrue, rightFalse := e.narrowCondition(expr.Right)
+			whenFalse = e.merge([]narrowing{leftFalse, e.overlay(leftTrue, rightFalse)})
+			e.popNarrowing()
+			whenTrue = e.overlay(leftTrue, rightTrue)
+		case token.OR:
+			// The right side is only evaluated if the left side is false
+			leftTrue, leftFalse := e.narrowCondition(expr.Left)
+			e.pushNarrowing(leftFalse)
+			rightTrue, rightFalse := e.narrowCondition(expr.Right)
+			whenTrue = e.merge([]narrowing{leftTrue, e.overlay(leftFalse, rightTrue)})
+			e.popNarrowing()
+			whenFalse = e.overlay(leftFalse, rightFalse)
+		case token.EQUAL:
+			return e.narrowComparison(expr)
+		case token.NEQ:
+			whenFalse, whenTrue = e.narrowComparison(expr)
+		}
+	}
+	return whenTrue, whenFalse
+}
+
+// overlay returns a narrowing with the variables of both, preferring top.
+func (e *Environment) overlay(base, top narrowing) narrowing {
+	result := narrowing{}
+	for def, typ := range base {
+		result[def] = typ
+	}
+	for def, typ := range top {
+		result[def] = typ
+	}
+	return result
+}
+
+// narrowComparison narrows `x == nil` and `type(x) == "name"`, in either
+// order.
+func (e *Environment) narrowComparison(expr *ast.InfixExpression) (whenTrue, whenFalse narrowing) {
+	whenTrue, whenFalse = narrowing{}, narrowing{}
+	left, right := expr.Left, expr.Right
+	if _, ok := left.(*ast.NilLiteral); ok {
+		left, right = right, left
+	}
+	if _, ok := left.(*ast.StringLiteral); ok {
+		left, right = right, left
+	}
+
+	// x == nil
+	if ident, ok := left.(*ast.Identifier); ok {
+		if _, ok := right.(*ast.NilLiteral); !ok {
+			return whenTrue, whenFalse
+		}
+		def := e.localDef(ident)
+		if def == nil {
+			return whenTrue, whenFalse
+		}
+		typ := typeOrUnknown(e.varType(def))
+		whenTrue[def] = &Nil{}
+		if t := truthy(typ); t != nil {
+			// false is not nil
+			whenFalse[def] = t
+		}
+		return whenTrue, whenFalse
+	}
+
+	// type(x) == "name"
+	call, ok := left.(*ast.FunctionCall)
+	if !ok || len(call.Args.Pairs) != 1 {
+		return whenTrue, whenFalse
+	}
+	name, ok := call.Name.(*ast.Identifier)
+	if !ok || name.Token.Literal != "type" || e.Scopes.Uses[name] != nil {
+		return whenTrue, whenFalse
+	}
+	ident, ok := call.Args.Pairs[0].Node.(*ast.Identifier)
+	lit, isString := right.(*ast.StringLiteral)
+	if !ok || !isString {
+		return whenTrue, whenFalse
+	}
+	def := e.localDef(ident)
+	typeName, ok := stringValue(lit)
+	if def == nil || !ok {
+		return whenTrue, whenFalse
+	}
+	matching, others := []Type{}, []Type{}
+	for _, member := range Members(typeOrUnknown(e.varType(def))) {
+		switch {
+		case kind(member) == typeName:
+			matching = append(matching, member)
+		case kind(member) == "any" || kind(member) == "unknown":
+			if typ := typeNamed(typeName); typ != nil {
+				matching = append(matching, typ)
+			}
+			others = append(others, member)
+		default:
+			others = append(others, member)
+		}
+	}
+	if len(matching) > 0 {
+		whenTrue[def] = NewUnion(matching...)
+	}
+	if len(others) > 0 {
+		whenFalse[def] = NewUnion(others...)
+	}
+	return whenTrue, whenFalse
+}
+
+// typeNamed returns the type that `type()` names, or nil if it is not
+// modelled.
+func typeNamed(name string) Type {
+	switch name {
+	case "boolean":
+		return &Boolean{}
+	case "function":
+		return &Function{Params: []NameAndType{}}
+	case "nil":
+		return &Nil{}
+	case "number":
+		return &Number{}
+	case "string":
+		return &String{}
+	case "table":
+		return &Table{}
+	}
+	return nil
+}
+
+// localDef returns the definition of a local variable, or nil if ident is a
+// global.
+func (e *Environment) localDef(ident *ast.Identifier) *ast.Identifier {
+	binding := e.Scopes.Uses[ident]
+	if binding == nil {
+		return nil
+	}
+	return binding.Ident
+}
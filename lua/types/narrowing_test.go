@@ -0,0 +1,109 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNarrowing(t *testing.T) {
+	src := `---@param x string?
+---@param y string|number
+---@param z boolean?
+local function f(x, y, z)
+  local before = x
+  if x then
+    local truthy = x
+  else
+    local falsy = x
+  end
+  if type(y) == "string" then
+    local isString = y
+  elseif type(y) == "number" then
+    local isNumber = y
+  end
+  if "number" ~= type(y) then
+    local notNumber = y
+  end
+  if z ~= nil then
+    local notNil = z
+  end
+  if z == nil then
+    local isNil = z
+  end
+  local guarded = x and #x
+  local default = x or 1
+  if not x then
+    return
+  end
+  local after = x
+end
+
+---@type number?
+local a
+local beforeAssert = a
+assert(a)
+local afterAssert = a
+
+---@type string|number|nil
+local b
+local beforeAssign = b
+b = 1
+local afterAssign = b
+if b == 1 then
+  b = "a"
+end
+local merged = b
+b = nil
+
+---@type string?
+local c
+if c == nil or c == "" then
+  c = "default"
+end
+local set = c
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "string?", typeAt("before ="))
+	assert.Equal(t, "string", typeAt("truthy ="))
+	assert.Equal(t, "nil", typeAt("falsy ="))
+	assert.Equal(t, "string", typeAt("isString ="))
+	assert.Equal(t, "number", typeAt("isNumber ="))
+	assert.Equal(t, "string", typeAt("notNumber ="))
+	assert.Equal(t, "boolean", typeAt("notNil ="))
+	assert.Equal(t, "nil", typeAt("isNil ="))
+	assert.Equal(t, "number?", typeAt("guarded ="))
+	assert.Equal(t, "string|number", typeAt("default ="))
+	assert.Equal(t, "string", typeAt("after ="))
+	assert.Equal(t, "number?", typeAt("beforeAssert ="))
+	assert.Equal(t, "number", typeAt("afterAssert ="))
+	assert.Equal(t, "string|number|nil", typeAt("beforeAssign ="))
+	assert.Equal(t, "number", typeAt("afterAssign ="))
+	assert.Equal(t, "string|number", typeAt("merged ="))
+	assert.Equal(t, "string", typeAt("set ="))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestNarrowingFunctions(t *testing.T) {
+	src := `---@type number?
+local x
+local function f()
+  if not x then
+    return
+  end
+  local inner = x
+end
+local outer = x
+`
+	env := newTestEnvironment(src)
+	assert.Equal(t, "number", env.Types[identAt(t, env, strings.Index(src, "inner ="))].String())
+	assert.Equal(t, "number?", env.Types[identAt(t, env, strings.Index(src, "outer ="))].String())
+}
@@ -0,0 +1,180 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// The metamethod that overloads each operator.
+var metamethods = map[token.TokenType]string{
+	token.PLUS:   "__add",
+	token.MINUS:  "__sub",
+	token.MUL:    "__mul",
+	token.SLASH:  "__div",
+	token.MOD:    "__mod",
+	token.POW:    "__pow",
+	token.IDIV:   "__idiv",
+	token.BAND:   "__band",
+	token.BOR:    "__bor",
+	token.BXOR:   "__bxor",
+	token.SHL:    "__shl",
+	token.SHR:    "__shr",
+	token.CONCAT: "__concat",
+	token.LT:     "__lt",
+	token.LEQ:    "__le",
+	token.GT:     "__lt",
+	token.GEQ:    "__le",
+}
+
+// The metamethod that overloads each unary operator.
+var unaryMetamethods = map[token.TokenType]string{
+	token.MINUS: "__unm",
+	token.BXOR:  "__bnot",
+	token.LEN:   "__len",
+}
+
+func (e *Environment) resolveInfixType(expr *ast.InfixExpression) Type {
+	left := typeOrUnknown(e.resolveExprType(expr.Left))
+	op := expr.Operator.Type()
+	// The right side of `and` and `or` is only evaluated if the left side is
+	// truthy or falsy respectively
+	narrowed := narrowing{}
+	switch op {
+	case token.AND:
+		narrowed, _ = e.narrowCondition(expr.Left)
+	case token.OR:
+		_, narrowed = e.narrowCondition(expr.Left)
+	}
+	e.pushNarrowing(narrowed)
+	right := typeOrUnknown(e.resolveExprType(expr.Right))
+	e.popNarrowing()
+	switch op {
+	case token.AND:
+		// The left side if it is falsy, otherwise the right side
+		if truthy(left) == nil {
+			return e.addType(expr, left)
+		}
+		return e.addType(expr, NewUnion(falsy(left), right))
+	case token.OR:
+		// The left side if it is truthy, otherwise the right side. `a and b`
+		// is only truthy as b, so `a and b or c` is b or c.
+		leftTruthy := truthy(left)
+		if and, ok := expr.Left.(*ast.InfixExpression); ok && and.Operator.Type() == token.AND {
+			leftTruthy = truthy(typeOrUnknown(e.Types[and.Right]))
+		}
+		return e.addType(expr, NewUnion(leftTruthy, right))
+	case token.EQUAL, token.NEQ:
+		return e.addType(expr, &Boolean{})
+	}
+
+	if typ := e.metamethodType(op, left, right); typ != nil {
+		return e.addType(expr, typ)
+	}
+	switch op {
+	case token.CONCAT:
+		e.checkOperand(expr.Left, left, "concatenate", false)
+		e.checkOperand(expr.Right, right, "concatenate", false)
+		return e.addType(expr, &String{})
+	case token.LT, token.LEQ, token.GT, token.GEQ:
+		e.checkComparison(expr, left, right)
+		return e.addType(expr, &Boolean{})
+	}
+	e.checkOperand(expr.Left, left, "perform arithmetic on", true)
+	e.checkOperand(expr.Right, right, "perform arithmetic on", true)
+	return e.addType(expr, &Number{})
+}
+
+func (e *Environment) resolvePrefixType(expr *ast.PrefixExpression) Type {
+	right := typeOrUnknown(e.resolveExprType(expr.Right))
+	op := expr.Operator.Type()
+	if op == token.NOT {
+		return e.addType(expr, &Boolean{})
+	}
+	if typ := e.metamethodTypeByName(unaryMetamethods[op], right); typ != nil {
+		return e.addType(expr, typ)
+	}
+	if op == token.LEN {
+		for _, member := range Members(right) {
+			switch member.(type) {
+			case *Any, *Unknown, *String, *Table:
+			default:
+				e.addError(operatorTypeMismatch, expr.Right, "Cannot get the length of a '%s' value", member)
+				return e.addType(expr, &Number{})
+			}
+		}
+		return e.addType(expr, &Number{})
+	}
+	e.checkOperand(expr.Right, right, "perform arithmetic on", true)
+	return e.addType(expr, &Number{})
+}
+
+// checkOperand reports members of typ that cannot be used with an arithmetic
+// or concatenation operator. Strings are converted to numbers in arithmetic,
+// which is reported unless the StringCoercion option is set. Numbers are
+// always allowed in concatenation.
+func (e *Environment) checkOperand(expr ast.Expression, typ Type, action string, arithmetic bool) {
+	for _, member := range Members(typ) {
+		switch member.(type) {
+		case *Any, *Unknown, *Number:
+			continue
+		case *String:
+			if arithmetic && !e.Options.StringCoercion {
+				e.addError(stringCoercion, expr, "String is implicitly converted to a number")
+			}
+			continue
+		}
+		e.addError(operatorTypeMismatch, expr, "Cannot %s a '%s' value", action, member)
+		return
+	}
+}
+
+// checkComparison reports order comparisons between types that are not both
+// numbers or both strings.
+func (e *Environment) checkComparison(expr *ast.InfixExpression, left, right Type) {
+	comparable := func(typ Type) bool {
+		for _, member := range Members(typ) {
+			switch member.(type) {
+			case *Any, *Unknown, *Number, *String:
+			default:
+				return false
+			}
+		}
+		return true
+	}
+	if !comparable(left) || !comparable(right) || (!IsAssignable(left, right) && !IsAssignable(right, left)) {
+		e.addError(operatorTypeMismatch, expr, "Cannot compare '%s' with '%s'", left, right)
+	}
+}
+
+// metamethodType returns the result type of the metamethod that overloads op
+// for either operand, or nil if there is none.
+func (e *Environment) metamethodType(op token.TokenType, left, right Type) Type {
+	name, ok := metamethods[op]
+	if !ok {
+		return nil
+	}
+	if typ := e.metamethodTypeByName(name, left); typ != nil {
+		return typ
+	}
+	return e.metamethodTypeByName(name, right)
+}
+
+func (e *Environment) metamethodTypeByName(name string, operand Type) Type {
+	tbl, ok := operand.(*Table)
+	if !ok || tbl.Metatable == nil {
+		return nil
+	}
+	field := tbl.Metatable.field(name)
+	if field == nil {
+		return nil
+	}
+	// Comparisons always convert the result to a boolean
+	if name == "__lt" || name == "__le" {
+		return &Boolean{}
+	}
+	fn, ok := field.Type.(*Function)
+	if !ok || fn.Returns == nil {
+		return &Unknown{}
+	}
+	return fn.Returns.At(0)
+}
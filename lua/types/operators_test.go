@@ -0,0 +1,87 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/raiguard/luapls/lua/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatorTypes(t *testing.T) {
+	src := `local num, str, bool = 1, "a", true
+local a = num + num
+local b = str .. num
+local c = num < num
+local d = #str
+local e = not num
+local f = -num
+local g = num == str
+local h = num // 2 | 1
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "number", typeAt("a ="))
+	assert.Equal(t, "string", typeAt("b ="))
+	assert.Equal(t, "boolean", typeAt("c ="))
+	assert.Equal(t, "number", typeAt("d ="))
+	assert.Equal(t, "boolean", typeAt("e ="))
+	assert.Equal(t, "number", typeAt("f ="))
+	assert.Equal(t, "boolean", typeAt("g ="))
+	assert.Equal(t, "number", typeAt("h ="))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestOperatorErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		message string
+		errText string
+	}{
+		{"local x = 1 + true", "Cannot perform arithmetic on a 'boolean' value", "true"},
+		{"local x = nil * 2", "Cannot perform arithmetic on a 'nil' value", "nil"},
+		{"local x = 1 .. true", "Cannot concatenate a 'boolean' value", "true"},
+		{"local x = 1 < 'a'", "Cannot compare 'number' with 'string'", "1 < 'a'"},
+		{"local x = #1", "Cannot get the length of a 'number' value", "1"},
+		{"local x = -true", "Cannot perform arithmetic on a 'boolean' value", "true"},
+		{"local x = 1 + 'a'", "String is implicitly converted to a number", "'a'"},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			env := newTestEnvironment(test.src)
+			errors := withoutUnused(env.Errors)
+			if assert.Len(t, errors, 1) {
+				err := errors[0]
+				assert.Equal(t, test.message, err.Message)
+				assert.Equal(t, test.errText, test.src[err.Range.Start:err.Range.End])
+			}
+		})
+	}
+
+	// The string coercion warning is optional
+	file := parser.New("local x = 1 + 'a'").ParseFile()
+	env := NewEnvironment(&file)
+	env.Options.StringCoercion = true
+	env.ResolveTypes()
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestMetamethods(t *testing.T) {
+	vector := &Table{Metatable: &Table{Fields: []NameAndType{
+		{Name: "__add", Type: &Function{Returns: NewTuple(&String{})}},
+		{Name: "__lt", Type: &Function{Returns: NewTuple(&Number{})}},
+	}}}
+	env := newTestEnvironment("")
+	assert.Equal(t, "string", env.metamethodType(token.PLUS, vector, &Number{}).String())
+	assert.Equal(t, "string", env.metamethodType(token.PLUS, &Number{}, vector).String())
+	assert.Equal(t, "boolean", env.metamethodType(token.LT, vector, vector).String())
+	assert.Nil(t, env.metamethodType(token.MINUS, vector, vector))
+	assert.Nil(t, env.metamethodType(token.PLUS, &Table{}, &Number{}))
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+)
+
+// Reference is an occurrence of a variable.
+type Reference struct {
+	Ident *ast.Identifier
+	Write bool // The reference is the definition or the target of an assignment
+}
+
+// resolveReferences indexes every variable occurrence in the file under its
+// definition. The definition is the first reference of its own list, except
+// for the implicit self of a method, which has no source location. Variables
+// without a definition are indexed under their name in Globals.
+func (e *Environment) resolveReferences() {
+	for _, ident := range e.Scopes.Order {
+		write := e.Scopes.Writes[ident]
+		binding := e.Scopes.Uses[ident]
+		if binding == nil {
+			name := ident.Token.Literal
+			e.Globals[name] = append(e.Globals[name], Reference{Ident: ident, Write: write})
+			continue
+		}
+		def := binding.Ident
+		refs := e.References[def]
+		if len(refs) == 0 && binding.Kind != SelfBinding {
+			refs = append(refs, Reference{Ident: def, Write: true})
+		}
+		if ident != def {
+			refs = append(refs, Reference{Ident: ident, Write: write})
+		}
+		e.References[def] = refs
+	}
+}
+
+// FindReferences returns every reference to the variable that ident refers
+// to, including its definition.
+func (e *Environment) FindReferences(ident *ast.Identifier) []Reference {
+	binding := e.Scopes.Uses[ident]
+	if binding == nil {
+		return nil
+	}
+	return e.References[binding.Ident]
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnvironment(src string) *Environment {
+	file := parser.New(src).ParseFile()
+	env := NewEnvironment(&file)
+	env.ResolveTypes()
+	return &env
+}
+
+// withoutUnused returns the errors, except for unused variables and labels,
+// which are common in test snippets.
+func withoutUnused(errors []ast.Error) []ast.Error {
+	filtered := []ast.Error{}
+	for _, err := range errors {
+		if !slices.Contains([]string{unusedFunction, unusedLabel, unusedLocal, unusedParameter}, err.Code) {
+			filtered = append(filtered, err)
+		}
+	}
+	return filtered
+}
+
+// identAt returns the identifier at the given byte offset.
+func identAt(t *testing.T, env *Environment, pos int) *ast.Identifier {
+	ident, ok := ast.GetNode(&env.file.Block, pos).Node.(*ast.Identifier)
+	require.True(t, ok, "No identifier at %d", pos)
+	return ident
+}
+
+func TestReferences(t *testing.T) {
+	src := `local foo = 1
+do
+  foo = foo + 1
+end
+local t = { foo = foo }
+print(t.foo, foo)
+`
+	env := newTestEnvironment(src)
+	refs := env.FindReferences(identAt(t, env, 6))
+
+	positions := []int{}
+	writes := []bool{}
+	for _, ref := range refs {
+		positions = append(positions, ref.Ident.Pos())
+		writes = append(writes, ref.Write)
+	}
+	assert.Equal(t, []int{6, 19, 25, 55, 74}, positions)
+	assert.Equal(t, []bool{true, true, false, false, false}, writes)
+
+	// Field names are not variables
+	assert.Empty(t, env.FindReferences(identAt(t, env, 49)))
+	assert.Empty(t, env.FindReferences(identAt(t, env, 69)))
+}
+
+func TestSelfReferences(t *testing.T) {
+	src := `local M = {}
+function M:f()
+  self.x = 1
+  return self
+end
+`
+	env := newTestEnvironment(src)
+	refs := env.FindReferences(identAt(t, env, 30))
+
+	positions := []int{}
+	for _, ref := range refs {
+		assert.NotEqual(t, ref.Ident.Pos(), ref.Ident.End())
+		positions = append(positions, ref.Ident.Pos())
+	}
+	assert.Equal(t, []int{30, 50}, positions)
+}
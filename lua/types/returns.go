@@ -0,0 +1,134 @@
+package types
+
+import (
+	"github.com/raiguard/luapls/lua/ast"
+)
+
+// functionFrame collects the returns of a function while its body is
+// resolved.
+type functionFrame struct {
+	typ      *Function
+	declared bool // The return types are annotated
+	returns  []*Tuple
+}
+
+// resolveFunctionBody resolves the body of a function. If its return types
+// are not annotated, they are inferred from its return statements.
+func (e *Environment) resolveFunctionBody(typ *Function, body *ast.Block) {
+	frame := &functionFrame{typ: typ, declared: typ.Returns != nil}
+	e.functions = append(e.functions, frame)
+	// Narrowing inside of the function does not apply outside of it
+	e.pushNarrowing(narrowing{})
+	e.resolveBlockTypes(body)
+	e.popNarrowing()
+	e.functions = e.functions[:len(e.functions)-1]
+	if frame.declared {
+		return
+	}
+	if !blockTerminates(body) {
+		// Falling off the end returns nothing
+		frame.returns = append(frame.returns, &Tuple{})
+	}
+	typ.Returns = unionTuples(frame.returns)
+}
+
+// resolveReturn resolves the values of a return statement, and checks them
+// against the annotated return types of the function.
+func (e *Environment) resolveReturn(stmt *ast.ReturnStatement) {
+	values := &Tuple{}
+	if stmt.Exps != nil {
+		values = e.resolveExprListValues(stmt.Exps)
+	}
+	if len(e.functions) == 0 {
+		e.moduleReturns = append(e.moduleReturns, values)
+		return
+	}
+	frame := e.functions[len(e.functions)-1]
+	if !frame.declared {
+		frame.returns = append(frame.returns, values)
+		return
+	}
+	declared := frame.typ.Returns
+	for i, typ := range declared.Types {
+		if value := values.At(i); !IsAssignable(value, typ) {
+			e.addError(returnTypeMismatch, e.returnNode(stmt, i), "Cannot return '%s' as '%s'", value, typ)
+		}
+	}
+	if len(values.Types) > len(declared.Types) && declared.Rest == nil {
+		e.addError(redundantReturnValue, e.returnNode(stmt, len(declared.Types)), "Too many return values, expected %v, got %v", len(declared.Types), len(values.Types))
+	}
+}
+
+// resolveModule sets the value that the file returns to require from its
+// return statements.
+func (e *Environment) resolveModule() {
+	returns := e.moduleReturns
+	if !blockTerminates(&e.file.Block) {
+		returns = append(returns, &Tuple{})
+	}
+	e.Module = unionTuples(returns).At(0)
+	if _, ok := e.Module.(*Nil); ok {
+		// require returns true for a module that returns nothing
+		e.Module = &Boolean{}
+	}
+}
+
+// returnNode returns the expression of the i-th returned value, or the
+// statement if there is none.
+func (e *Environment) returnNode(stmt *ast.ReturnStatement, i int) ast.Node {
+	if stmt.Exps == nil || len(stmt.Exps.Pairs) == 0 {
+		return stmt
+	}
+	return stmt.Exps.Pairs[min(i, len(stmt.Exps.Pairs)-1)].Node
+}
+
+// unionTuples returns a tuple whose values are the unions of the values of
+// the given tuples at each position.
+func unionTuples(tuples []*Tuple) *Tuple {
+	length := 0
+	rests := []Type{}
+	for _, tuple := range tuples {
+		length = max(length, len(tuple.Types))
+		if tuple.Rest != nil {
+			rests = append(rests, tuple.Rest)
+		}
+	}
+	result := &Tuple{}
+	for i := 0; i < length; i++ {
+		members := make([]Type, len(tuples))
+		for j, tuple := range tuples {
+			members[j] = tuple.At(i)
+		}
+		result.Types = append(result.Types, NewUnion(members...))
+	}
+	if len(rests) > 0 {
+		result.Rest = NewUnion(rests...)
+	}
+	return result
+}
+
+// blockTerminates returns whether execution can never reach the end of the
+// block, because it returns or jumps elsewhere.
+func blockTerminates(block *ast.Block) bool {
+	if len(block.Pairs) == 0 {
+		return false
+	}
+	switch stmt := block.Pairs[len(block.Pairs)-1].Node.(type) {
+	case *ast.ReturnStatement, *ast.BreakStatement, *ast.GotoStatement:
+		return true
+	case *ast.DoStatement:
+		return blockTerminates(&stmt.Body)
+	case *ast.IfStatement:
+		// Every branch must terminate, including the implicit else
+		if len(stmt.Clauses) == 0 || stmt.Clauses[len(stmt.Clauses)-1].Condition != nil {
+			return false
+		}
+		for _, clause := range stmt.Clauses {
+			if !blockTerminates(&clause.Body) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnInference(t *testing.T) {
+	src := `---@param a number
+---@param b number
+local function add(a, b)
+  return a + b
+end
+local x = add(1, 2)
+
+local function maybe(flag)
+  if flag then
+    return "yes"
+  end
+end
+
+local function branches(flag)
+  if flag then
+    return 1, "a"
+  elseif flag == 2 then
+    return true
+  else
+    return 2, "b"
+  end
+end
+
+local function none() end
+
+local function forward(...)
+  return 1, ...
+end
+
+local anonymous = function() return {} end
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "function(a: number, b: number) → number", typeAt("add(a"))
+	assert.Equal(t, "number", typeAt("x ="))
+	assert.Equal(t, "function(flag: unknown) → string?", typeAt("maybe"))
+	assert.Equal(t, "function(flag: unknown) → number|boolean, string?", typeAt("branches"))
+	assert.Equal(t, "function()", typeAt("none"))
+	assert.Equal(t, "function(...: unknown) → number, ...unknown", typeAt("forward"))
+	assert.Equal(t, "function() → {}", typeAt("anonymous"))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestReturnChecks(t *testing.T) {
+	src := `---@return number
+local function f()
+  if true then
+    return "a"
+  end
+  return 1, 2
+end
+`
+	env := newTestEnvironment(src)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message+": "+src[err.Range.Start:err.Range.End])
+	}
+	assert.Equal(t, []string{
+		`Cannot return 'string' as 'number': "a"`,
+		"Too many return values, expected 1, got 2: 2",
+	}, errors)
+}
@@ -0,0 +1,331 @@
+package types
+
+import (
+	"math"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+type BindingKind int
+
+const (
+	LocalBinding BindingKind = iota
+	LocalFunctionBinding
+	ParamBinding
+	ForBinding
+	SelfBinding // The implicit `self` parameter of a method declared with `:`
+)
+
+// Binding is a local variable declaration.
+type Binding struct {
+	Name string
+	// The declaring identifier. For self this is a zero-width identifier at
+	// the `:` of the method name.
+	Ident   *ast.Identifier
+	Kind    BindingKind
+	Visible token.Range // Where the binding can be referenced
+}
+
+// Scope is a region of the file that can contain local variables.
+type Scope struct {
+	Parent   *Scope
+	Range    token.Range
+	Bindings []*Binding // In declaration order
+	Children []*Scope
+}
+
+// Scopes is the symbol table of a file. It is built once per parse.
+type Scopes struct {
+	Root *Scope
+	// Every variable occurrence, including declarations, mapped to its
+	// binding. Globals map to nil.
+	Uses   map[*ast.Identifier]*Binding
+	Writes map[*ast.Identifier]bool // Declarations and assignment targets
+	Order  []*ast.Identifier        // The keys of Uses in source order
+	// Assignments to global variables, by name
+	GlobalWrites map[string][]*ast.Identifier
+}
+
+// BuildScopes resolves every variable in the block.
+func BuildScopes(block *ast.Block) *Scopes {
+	b := scopeBuilder{
+		scopes: &Scopes{
+			Root:         &Scope{Range: token.Range{Start: 0, End: math.MaxInt}},
+			Uses:         map[*ast.Identifier]*Binding{},
+			Writes:       map[*ast.Identifier]bool{},
+			GlobalWrites: map[string][]*ast.Identifier{},
+		},
+	}
+	b.scope = b.scopes.Root
+	b.visitBlock(block)
+	return b.scopes
+}
+
+// Lookup returns the binding for name that is visible at pos, or nil if name
+// refers to a global there.
+func (s *Scopes) Lookup(name string, pos token.Pos) *Binding {
+	for scope := s.Root.innermost(pos); scope != nil; scope = scope.Parent {
+		for i := len(scope.Bindings) - 1; i >= 0; i-- {
+			binding := scope.Bindings[i]
+			if binding.Name == name && binding.Visible.Start <= pos && pos < binding.Visible.End {
+				return binding
+			}
+		}
+	}
+	return nil
+}
+
+// Visible returns every binding that is visible at pos, by name.
+func (s *Scopes) Visible(pos token.Pos) map[string]*Binding {
+	bindings := map[string]*Binding{}
+	for scope := s.Root.innermost(pos); scope != nil; scope = scope.Parent {
+		for i := len(scope.Bindings) - 1; i >= 0; i-- {
+			binding := scope.Bindings[i]
+			if _, shadowed := bindings[binding.Name]; shadowed {
+				continue
+			}
+			if binding.Visible.Start <= pos && pos < binding.Visible.End {
+				bindings[binding.Name] = binding
+			}
+		}
+	}
+	return bindings
+}
+
+func (s *Scope) innermost(pos token.Pos) *Scope {
+	for _, child := range s.Children {
+		if child.Range.Start <= pos && pos < child.Range.End {
+			return child.innermost(pos)
+		}
+	}
+	return s
+}
+
+type scopeBuilder struct {
+	scopes *Scopes
+	scope  *Scope
+}
+
+func (b *scopeBuilder) push(start, end token.Pos) {
+	scope := &Scope{Parent: b.scope, Range: token.Range{Start: start, End: max(start, end)}}
+	b.scope.Children = append(b.scope.Children, scope)
+	b.scope = scope
+}
+
+func (b *scopeBuilder) pop() {
+	b.scope = b.scope.Parent
+}
+
+// declare adds a binding to the current scope that is visible from start until
+// the end of the scope.
+func (b *scopeBuilder) declare(ident *ast.Identifier, kind BindingKind, start token.Pos) {
+	if ident == nil {
+		return
+	}
+	binding := &Binding{
+		Name:    ident.Token.Literal,
+		Ident:   ident,
+		Kind:    kind,
+		Visible: token.Range{Start: start, End: b.scope.Range.End},
+	}
+	b.scope.Bindings = append(b.scope.Bindings, binding)
+	b.record(ident, binding, true)
+}
+
+func (b *scopeBuilder) use(ident *ast.Identifier, write bool) {
+	if ident == nil {
+		return
+	}
+	b.record(ident, b.lookup(ident.Token.Literal), write)
+}
+
+func (b *scopeBuilder) record(ident *ast.Identifier, binding *Binding, write bool) {
+	b.scopes.Uses[ident] = binding
+	b.scopes.Order = append(b.scopes.Order, ident)
+	if write {
+		b.scopes.Writes[ident] = true
+		if binding == nil {
+			name := ident.Token.Literal
+			b.scopes.GlobalWrites[name] = append(b.scopes.GlobalWrites[name], ident)
+		}
+	}
+}
+
+// lookup returns the innermost binding declared so far. Since the builder
+// visits the file in order, this is the binding that is in scope.
+func (b *scopeBuilder) lookup(name string) *Binding {
+	for scope := b.scope; scope != nil; scope = scope.Parent {
+		for i := len(scope.Bindings) - 1; i >= 0; i-- {
+			if scope.Bindings[i].Name == name {
+				return scope.Bindings[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (b *scopeBuilder) visitBlock(block *ast.Block) {
+	for _, pair := range block.Pairs {
+		b.visitStmt(pair.Node)
+	}
+}
+
+func (b *scopeBuilder) visitStmt(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.AssignmentStatement:
+		for _, pair := range stmt.Vars.Pairs {
+			if ident, ok := pair.Node.(*ast.Identifier); ok {
+				b.use(ident, true)
+			} else {
+				b.visitExpr(pair.Node)
+			}
+		}
+		b.visitExprList(&stmt.Exps)
+	case *ast.DoStatement:
+		b.push(stmt.DoTok.End(), stmt.EndTok.Pos())
+		b.visitBlock(&stmt.Body)
+		b.pop()
+	case *ast.ForStatement:
+		b.visitExpr(stmt.Start.Node)
+		b.visitExpr(stmt.Finish.Node)
+		if stmt.Step != nil {
+			b.visitExpr(stmt.Step.Node)
+		}
+		b.push(stmt.DoTok.Pos(), stmt.EndTok.Pos())
+		b.declare(stmt.Name, ForBinding, b.scope.Range.Start)
+		b.visitBlock(&stmt.Body)
+		b.pop()
+	case *ast.ForInStatement:
+		b.visitExprList(&stmt.Exps)
+		b.push(stmt.DoTok.Pos(), stmt.EndTok.Pos())
+		for _, pair := range stmt.Names.Pairs {
+			b.declare(pair.Node, ForBinding, b.scope.Range.Start)
+		}
+		b.visitBlock(&stmt.Body)
+		b.pop()
+	case *ast.FunctionCall:
+		b.visitExpr(stmt)
+	case *ast.FunctionStatement:
+		var self *ast.Identifier
+		switch name := stmt.Name.(type) {
+		case *ast.Identifier:
+			if stmt.LocalTok != nil {
+				// Visible in its own body for recursion
+				b.declare(name, LocalFunctionBinding, name.Pos())
+			} else {
+				b.use(name, true)
+			}
+		case *ast.IndexExpression:
+			b.visitExpr(name)
+			if name.LeftIndexer.Type() == token.COLON {
+				self = &ast.Identifier{Token: token.Token{Type: token.IDENT, Pos: name.LeftIndexer.Pos()}}
+			}
+		default:
+			b.visitExpr(name)
+		}
+		b.visitFunction(self, &stmt.LeftParen, &stmt.Params, &stmt.Body, &stmt.EndTok)
+	case *ast.IfStatement:
+		for i, clause := range stmt.Clauses {
+			if clause.Condition != nil {
+				b.visitExpr(clause.Condition)
+			}
+			start := clause.LeadingTok.End()
+			if clause.ThenTok != nil {
+				start = clause.ThenTok.End()
+			}
+			end := stmt.EndTok.Pos()
+			if i+1 < len(stmt.Clauses) {
+				end = stmt.Clauses[i+1].Pos()
+			}
+			b.push(start, end)
+			b.visitBlock(&clause.Body)
+			b.pop()
+		}
+	case *ast.LocalStatement:
+		if stmt.Exps != nil {
+			b.visitExprList(stmt.Exps)
+		}
+		// Not visible in its own expressions
+		for _, pair := range stmt.Names.Pairs {
+			b.declare(pair.Node, LocalBinding, stmt.End())
+		}
+	case *ast.RepeatStatement:
+		// Locals in the body are visible in the condition
+		end := stmt.UntilTok.End()
+		if stmt.Condition != nil {
+			end = stmt.Condition.End()
+		}
+		b.push(stmt.RepeatTok.End(), end)
+		b.visitBlock(&stmt.Body)
+		if stmt.Condition != nil {
+			b.visitExpr(stmt.Condition)
+		}
+		b.pop()
+	case *ast.ReturnStatement:
+		if stmt.Exps != nil {
+			b.visitExprList(stmt.Exps)
+		}
+	case *ast.WhileStatement:
+		b.visitExpr(stmt.Condition)
+		b.push(stmt.DoTok.End(), stmt.EndTok.Pos())
+		b.visitBlock(&stmt.Body)
+		b.pop()
+	}
+}
+
+func (b *scopeBuilder) visitFunction(self *ast.Identifier, leftParen *ast.Unit, params *ast.Punctuated[*ast.Identifier], body *ast.Block, endTok *ast.Unit) {
+	b.push(leftParen.Pos(), endTok.Pos())
+	if self != nil {
+		binding := &Binding{Name: "self", Ident: self, Kind: SelfBinding, Visible: b.scope.Range}
+		b.scope.Bindings = append(b.scope.Bindings, binding)
+		b.scopes.Uses[self] = binding
+		b.scopes.Writes[self] = true
+	}
+	for _, pair := range params.Pairs {
+		b.declare(pair.Node, ParamBinding, b.scope.Range.Start)
+	}
+	b.visitBlock(body)
+	b.pop()
+}
+
+func (b *scopeBuilder) visitExprList(list *ast.Punctuated[ast.Expression]) {
+	for _, pair := range list.Pairs {
+		b.visitExpr(pair.Node)
+	}
+}
+
+func (b *scopeBuilder) visitExpr(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.FunctionCall:
+		b.visitExpr(expr.Name)
+		b.visitExprList(&expr.Args)
+	case *ast.FunctionExpression:
+		b.visitFunction(nil, &expr.LeftParen, &expr.Params, &expr.Body, &expr.EndUnit)
+	case *ast.Identifier:
+		b.use(expr, false)
+	case *ast.IndexExpression:
+		b.visitExpr(expr.Prefix)
+		// `a.b` and `a:b` index with a name, not a variable
+		if expr.LeftIndexer.Type() == token.LBRACK {
+			b.visitExpr(expr.Inner)
+		}
+	case *ast.InfixExpression:
+		b.visitExpr(expr.Left)
+		b.visitExpr(expr.Right)
+	case *ast.PrefixExpression:
+		b.visitExpr(expr.Right)
+	case *ast.TableLiteral:
+		for _, pair := range expr.Fields.Pairs {
+			switch field := pair.Node.(type) {
+			case *ast.TableArrayField:
+				b.visitExpr(field.Expr)
+			case *ast.TableExpressionKeyField:
+				b.visitExpr(field.Name)
+				b.visitExpr(field.Expr)
+			case *ast.TableSimpleKeyField:
+				b.visitExpr(field.Expr)
+			}
+		}
+	}
+}
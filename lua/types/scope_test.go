@@ -0,0 +1,81 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopes(t *testing.T) {
+	src := `local x = 1
+local x = x
+local function fact(n)
+  if n > 1 then
+    local r = n * fact(n - 1)
+    return r
+  end
+  return 1
+end
+for i = 1, 10 do
+  print(i)
+end
+repeat
+  local done = true
+until done
+function obj:method(a)
+  return self, a
+end
+y = x
+`
+	file := parser.New(src).ParseFile()
+	scopes := BuildScopes(&file.Block)
+
+	// find returns the nth occurrence of the identifier name
+	find := func(name string, n int) *ast.Identifier {
+		matches := regexp.MustCompile(`\b`+name+`\b`).FindAllStringIndex(src, -1)
+		require.Greater(t, len(matches), n)
+		ident, ok := ast.GetNode(&file.Block, matches[n][0]).Node.(*ast.Identifier)
+		require.True(t, ok, "No identifier at %d", matches[n][0])
+		return ident
+	}
+	def := func(name string, n int) *ast.Identifier {
+		binding := scopes.Uses[find(name, n)]
+		if binding == nil {
+			return nil
+		}
+		return binding.Ident
+	}
+
+	assert.Same(t, find("x", 0), def("x", 2), "local x = x refers to the previous x")
+	assert.Same(t, find("x", 1), def("x", 3))
+	assert.Same(t, find("fact", 0), def("fact", 1), "local functions are visible in their body")
+	assert.Same(t, find("n", 0), def("n", 2))
+	assert.Same(t, find("r", 0), def("r", 1))
+	assert.Same(t, find("i", 0), def("i", 1))
+	assert.Same(t, find("done", 0), def("done", 1), "locals are visible in until")
+	assert.Same(t, find("a", 0), def("a", 1))
+	assert.Nil(t, def("print", 0))
+	assert.Nil(t, def("y", 0))
+	assert.True(t, scopes.Writes[find("y", 0)])
+	assert.Equal(t, []*ast.Identifier{find("y", 0)}, scopes.GlobalWrites["y"])
+	assert.NotContains(t, scopes.GlobalWrites, "obj")
+
+	self := scopes.Uses[find("self", 0)]
+	require.NotNil(t, self)
+	assert.Equal(t, SelfBinding, self.Kind)
+
+	// Positional lookup
+	assert.Nil(t, scopes.Lookup("r", strings.Index(src, "return 1")))
+	assert.Nil(t, scopes.Lookup("i", strings.Index(src, "repeat")))
+	assert.Same(t, find("x", 1), scopes.Lookup("x", len(src)-1).Ident)
+	visible := scopes.Visible(strings.Index(src, "return r"))
+	assert.Contains(t, visible, "r")
+	assert.Contains(t, visible, "n")
+	assert.Contains(t, visible, "fact")
+	assert.Same(t, find("x", 1), visible["x"].Ident)
+}
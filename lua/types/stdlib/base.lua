@@ -0,0 +1,628 @@
+-- Definitions shared by every Lua version.
+
+--- Raises an error if `v` is false or nil, otherwise returns all of its arguments.
+---@generic T
+---@param v T
+---@param message? any
+---@param ... any
+---@return T
+---@return ...any
+function assert(v, message, ...) end
+
+--- Raises an error with `message` as the error object.
+---@param message any
+---@param level? integer
+function error(message, level) end
+
+--- Returns the metatable of `object`, or nil if it has none.
+---@param object any
+---@return table?
+function getmetatable(object) end
+
+--- Returns an iterator over the array part of `t`.
+---@generic V
+---@param t V[]
+---@return (fun(t: V[], i: integer): integer, V)
+---@return V[]
+---@return integer
+function ipairs(t) end
+
+--- Returns the next key of `t` after `index`, and its value.
+---@generic K, V
+---@param t table<K, V>
+---@param index? K
+---@return K?
+---@return V
+function next(t, index) end
+
+--- Returns an iterator over every key and value of `t`.
+---@generic K, V
+---@param t table<K, V>
+---@return (fun(t: table<K, V>, k?: K): K, V)
+---@return table<K, V>
+---@return nil
+function pairs(t) end
+
+--- Calls `f` in protected mode, and returns whether it succeeded followed by its results or error.
+---@param f function
+---@param ... any
+---@return boolean success
+---@return ...any
+function pcall(f, ...) end
+
+--- Writes its arguments to stdout, converted with tostring.
+---@param ... any
+function print(...) end
+
+--- Returns whether `v1` and `v2` are equal without calling metamethods.
+---@param v1 any
+---@param v2 any
+---@return boolean
+function rawequal(v1, v2) end
+
+--- Returns `t[k]` without calling metamethods.
+---@param t table
+---@param k any
+---@return any
+function rawget(t, k) end
+
+--- Sets `t[k]` to `v` without calling metamethods.
+---@param t table
+---@param k any
+---@param v any
+---@return table
+function rawset(t, k, v) end
+
+--- Returns the number of extra arguments if `index` is `"#"`, otherwise the arguments after `index`.
+---@param index integer|"#"
+---@param ... any
+---@return ...any
+function select(index, ...) end
+
+--- Sets the metatable of `t`, and returns `t`.
+---@param t table
+---@param metatable? table
+---@return table
+function setmetatable(t, metatable) end
+
+--- Converts `e` to a number, or returns nil if it cannot be converted.
+---@param e any
+---@param base? integer
+---@return number?
+function tonumber(e, base) end
+
+--- Converts `v` to a string.
+---@param v any
+---@return string
+function tostring(v) end
+
+--- Returns the name of the type of `v`.
+---@param v any
+---@return "nil"|"number"|"string"|"boolean"|"table"|"function"|"thread"|"userdata"
+function type(v) end
+
+--- Loads and runs the file `filename`, and returns its results.
+---@param filename? string
+---@return ...any
+function dofile(filename) end
+
+--- Loads `modname`, and returns the value that it returned.
+---@param modname string
+---@return any
+function require(modname) end
+
+--- Controls the garbage collector.
+---@param opt? string
+---@param arg? integer
+---@return any
+function collectgarbage(opt, arg) end
+
+---@type table<string, any>
+_G = {}
+
+_VERSION = ""
+
+---@class file
+local file = {}
+
+--- Closes the file.
+---@return boolean?
+---@return string? errmsg
+function file:close() end
+
+--- Saves written data to the file.
+---@return file?
+---@return string? errmsg
+function file:flush() end
+
+--- Returns an iterator over the lines of the file.
+---@param ... string|integer
+---@return (fun(): string?)
+function file:lines(...) end
+
+--- Reads from the file in the given formats.
+---@param ... string|integer
+---@return ...any
+function file:read(...) end
+
+--- Sets and returns the position in the file.
+---@param whence? "set"|"cur"|"end"
+---@param offset? integer
+---@return integer?
+---@return string? errmsg
+function file:seek(whence, offset) end
+
+--- Sets the buffering mode of the file.
+---@param mode "no"|"full"|"line"
+---@param size? integer
+---@return boolean?
+---@return string? errmsg
+function file:setvbuf(mode, size) end
+
+--- Writes each argument to the file.
+---@param ... string|number
+---@return file?
+---@return string? errmsg
+function file:write(...) end
+
+coroutine = {}
+
+--- Creates a coroutine that runs `f`.
+---@param f function
+---@return any
+function coroutine.create(f) end
+
+--- Starts or continues the coroutine `co`.
+---@param co any
+---@param ... any
+---@return boolean success
+---@return ...any
+function coroutine.resume(co, ...) end
+
+--- Returns the running coroutine.
+---@return any
+function coroutine.running() end
+
+--- Returns the status of the coroutine `co`.
+---@param co any
+---@return "running"|"suspended"|"normal"|"dead"
+function coroutine.status(co) end
+
+--- Creates a coroutine that runs `f`, and returns a function that resumes it.
+---@param f function
+---@return function
+function coroutine.wrap(f) end
+
+--- Suspends the running coroutine.
+---@param ... any
+---@return ...any
+function coroutine.yield(...) end
+
+debug = {}
+
+--- Enters interactive debugging mode.
+function debug.debug() end
+
+--- Returns the current hook function, mask and count.
+---@param thread? any
+---@return function?
+---@return string
+---@return integer
+function debug.gethook(thread) end
+
+--- Returns information about a function or stack level.
+---@param f integer|function
+---@param what? string
+---@return table?
+function debug.getinfo(f, what) end
+
+--- Returns the name and value of a local variable.
+---@param level integer
+---@param index integer
+---@return string?
+---@return any
+function debug.getlocal(level, index) end
+
+--- Returns the metatable of `value`.
+---@param value any
+---@return table?
+function debug.getmetatable(value) end
+
+--- Returns the registry table.
+---@return table
+function debug.getregistry() end
+
+--- Returns the name and value of an upvalue of `f`.
+---@param f function
+---@param up integer
+---@return string?
+---@return any
+function debug.getupvalue(f, up) end
+
+--- Sets a debug hook.
+---@param hook? function
+---@param mask? string
+---@param count? integer
+function debug.sethook(hook, mask, count) end
+
+--- Sets the value of a local variable.
+---@param level integer
+---@param index integer
+---@param value any
+---@return string?
+function debug.setlocal(level, index, value) end
+
+--- Sets the metatable of `value`.
+---@generic T
+---@param value T
+---@param metatable? table
+---@return T
+function debug.setmetatable(value, metatable) end
+
+--- Sets the value of an upvalue of `f`.
+---@param f function
+---@param up integer
+---@param value any
+---@return string?
+function debug.setupvalue(f, up, value) end
+
+--- Returns a traceback of the call stack.
+---@param message? any
+---@param level? integer
+---@return string
+function debug.traceback(message, level) end
+
+io = {}
+
+io.stdin = file
+io.stdout = file
+io.stderr = file
+
+--- Closes `file`, or the default output file.
+---@param file? file
+---@return boolean?
+---@return string? errmsg
+function io.close(file) end
+
+--- Saves written data to the default output file.
+function io.flush() end
+
+--- Sets or returns the default input file.
+---@param file? string|file
+---@return file
+function io.input(file) end
+
+--- Returns an iterator over the lines of `filename`, or the default input file.
+---@param filename? string
+---@param ... string|integer
+---@return (fun(): string?)
+function io.lines(filename, ...) end
+
+--- Opens `filename` in the given mode.
+---@param filename string
+---@param mode? string
+---@return file?
+---@return string? errmsg
+function io.open(filename, mode) end
+
+--- Sets or returns the default output file.
+---@param file? string|file
+---@return file
+function io.output(file) end
+
+--- Starts `prog` in a separate process, and returns a file connected to it.
+---@param prog string
+---@param mode? "r"|"w"
+---@return file?
+---@return string? errmsg
+function io.popen(prog, mode) end
+
+--- Reads from the default input file in the given formats.
+---@param ... string|integer
+---@return ...any
+function io.read(...) end
+
+--- Returns a handle for a temporary file.
+---@return file
+function io.tmpfile() end
+
+--- Returns whether `obj` is an open file, a closed file, or not a file.
+---@param obj any
+---@return "file"|"closed file"|nil
+function io.type(obj) end
+
+--- Writes each argument to the default output file.
+---@param ... string|number
+---@return file?
+---@return string? errmsg
+function io.write(...) end
+
+math = {}
+
+math.huge = 1 / 0
+math.pi = 3.141592653589793
+
+--- Returns the absolute value of `x`.
+---@param x number
+---@return number
+function math.abs(x) end
+
+--- Returns the arc cosine of `x`, in radians.
+---@param x number
+---@return number
+function math.acos(x) end
+
+--- Returns the arc sine of `x`, in radians.
+---@param x number
+---@return number
+function math.asin(x) end
+
+--- Returns the smallest integer larger than or equal to `x`.
+---@param x number
+---@return integer
+function math.ceil(x) end
+
+--- Returns the cosine of `x`, in radians.
+---@param x number
+---@return number
+function math.cos(x) end
+
+--- Converts the angle `x` from radians to degrees.
+---@param x number
+---@return number
+function math.deg(x) end
+
+--- Returns e raised to the power of `x`.
+---@param x number
+---@return number
+function math.exp(x) end
+
+--- Returns the largest integer smaller than or equal to `x`.
+---@param x number
+---@return integer
+function math.floor(x) end
+
+--- Returns the remainder of the division of `x` by `y`, rounded towards zero.
+---@param x number
+---@param y number
+---@return number
+function math.fmod(x, y) end
+
+--- Returns the largest argument.
+---@param x number
+---@param ... number
+---@return number
+function math.max(x, ...) end
+
+--- Returns the smallest argument.
+---@param x number
+---@param ... number
+---@return number
+function math.min(x, ...) end
+
+--- Returns the integral and fractional parts of `x`.
+---@param x number
+---@return number
+---@return number
+function math.modf(x) end
+
+--- Converts the angle `x` from degrees to radians.
+---@param x number
+---@return number
+function math.rad(x) end
+
+--- Returns a pseudo-random number. With arguments, it is an integer in the given range.
+---@param m? integer
+---@param n? integer
+---@return number
+function math.random(m, n) end
+
+--- Sets the seed of the pseudo-random generator.
+---@param x? integer
+function math.randomseed(x) end
+
+--- Returns the sine of `x`, in radians.
+---@param x number
+---@return number
+function math.sin(x) end
+
+--- Returns the square root of `x`.
+---@param x number
+---@return number
+function math.sqrt(x) end
+
+--- Returns the tangent of `x`, in radians.
+---@param x number
+---@return number
+function math.tan(x) end
+
+os = {}
+
+--- Returns the CPU time used by the program, in seconds.
+---@return number
+function os.clock() end
+
+--- Formats a time as a string, or a table if the format starts with `*t` or `!*t`.
+---@param format? string
+---@param time? integer
+---@return string|table
+function os.date(format, time) end
+
+--- Returns the number of seconds between `t1` and `t2`.
+---@param t2 integer
+---@param t1 integer
+---@return number
+function os.difftime(t2, t1) end
+
+--- Terminates the program.
+---@param code? boolean|integer
+---@param close? boolean
+function os.exit(code, close) end
+
+--- Returns the value of the environment variable `varname`.
+---@param varname string
+---@return string?
+function os.getenv(varname) end
+
+--- Deletes the file `filename`.
+---@param filename string
+---@return boolean?
+---@return string? errmsg
+function os.remove(filename) end
+
+--- Renames the file `oldname` to `newname`.
+---@param oldname string
+---@param newname string
+---@return boolean?
+---@return string? errmsg
+function os.rename(oldname, newname) end
+
+--- Sets the current locale of the program.
+---@param locale? string
+---@param category? string
+---@return string?
+function os.setlocale(locale, category) end
+
+--- Returns the current time, or the time described by `date`.
+---@param date? table
+---@return integer
+function os.time(date) end
+
+--- Returns a name for a temporary file.
+---@return string
+function os.tmpname() end
+
+package = {}
+
+package.cpath = ""
+package.path = ""
+---@type table<string, any>
+package.loaded = {}
+---@type table<string, function>
+package.preload = {}
+
+--- Loads the C library `libname`.
+---@param libname string
+---@param funcname string
+---@return function?
+function package.loadlib(libname, funcname) end
+
+string = {}
+
+--- Returns the byte values of the characters from `i` to `j`.
+---@param s string
+---@param i? integer
+---@param j? integer
+---@return ...integer
+function string.byte(s, i, j) end
+
+--- Returns a string of the characters with the given byte values.
+---@param ... integer
+---@return string
+function string.char(...) end
+
+--- Returns a binary representation of the function `f`.
+---@param f function
+---@return string
+function string.dump(f) end
+
+--- Finds the first match of `pattern` in `s`, and returns its start and end followed by its captures.
+---@param s string
+---@param pattern string
+---@param init? integer
+---@param plain? boolean
+---@return integer? start
+---@return integer? end
+---@return ...any
+function string.find(s, pattern, init, plain) end
+
+--- Formats its arguments according to `format`.
+---@param format string
+---@param ... any
+---@return string
+function string.format(format, ...) end
+
+--- Returns an iterator over the matches of `pattern` in `s`.
+---@param s string
+---@param pattern string
+---@return (fun(): string, ...any)
+function string.gmatch(s, pattern) end
+
+--- Replaces the matches of `pattern` in `s`, and returns the result and the number of matches.
+---@param s string
+---@param pattern string
+---@param repl string|table|function
+---@param n? integer
+---@return string
+---@return integer count
+function string.gsub(s, pattern, repl, n) end
+
+--- Returns the length of `s`.
+---@param s string
+---@return integer
+function string.len(s) end
+
+--- Converts `s` to lowercase.
+---@param s string
+---@return string
+function string.lower(s) end
+
+--- Returns the captures of the first match of `pattern` in `s`.
+---@param s string
+---@param pattern string
+---@param init? integer
+---@return ...any
+function string.match(s, pattern, init) end
+
+--- Returns `n` copies of `s`.
+---@param s string
+---@param n integer
+---@return string
+function string.rep(s, n) end
+
+--- Reverses `s`.
+---@param s string
+---@return string
+function string.reverse(s) end
+
+--- Returns the substring of `s` from `i` to `j`.
+---@param s string
+---@param i integer
+---@param j? integer
+---@return string
+function string.sub(s, i, j) end
+
+--- Converts `s` to uppercase.
+---@param s string
+---@return string
+function string.upper(s) end
+
+table = {}
+
+--- Concatenates the elements of `list` from `i` to `j`, separated by `sep`.
+---@param list table
+---@param sep? string
+---@param i? integer
+---@param j? integer
+---@return string
+function table.concat(list, sep, i, j) end
+
+--- Inserts `value` at `pos` in `list`, or appends `pos` if there is no value.
+---@generic T
+---@param list T[]
+---@param pos integer|T
+---@param value? T
+function table.insert(list, pos, value) end
+
+--- Removes the element at `pos` from `list`, or the last element, and returns it.
+---@generic T
+---@param list T[]
+---@param pos? integer
+---@return T?
+function table.remove(list, pos) end
+
+--- Sorts `list` in place.
+---@generic T
+---@param list T[]
+---@param comp? fun(a: T, b: T): boolean
+function table.sort(list, comp) end
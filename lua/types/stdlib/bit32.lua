@@ -0,0 +1,73 @@
+-- The bit32 library of Lua 5.2.
+
+bit32 = {}
+
+--- Returns `x` shifted arithmetically by `disp` bits to the right.
+---@param x integer
+---@param disp integer
+---@return integer
+function bit32.arshift(x, disp) end
+
+--- Returns the bitwise and of its arguments.
+---@param ... integer
+---@return integer
+function bit32.band(...) end
+
+--- Returns the bitwise negation of `x`.
+---@param x integer
+---@return integer
+function bit32.bnot(x) end
+
+--- Returns the bitwise or of its arguments.
+---@param ... integer
+---@return integer
+function bit32.bor(...) end
+
+--- Returns whether the bitwise and of its arguments is not zero.
+---@param ... integer
+---@return boolean
+function bit32.btest(...) end
+
+--- Returns the bitwise exclusive or of its arguments.
+---@param ... integer
+---@return integer
+function bit32.bxor(...) end
+
+--- Returns the bits `field` to `field + width - 1` of `n`.
+---@param n integer
+---@param field integer
+---@param width? integer
+---@return integer
+function bit32.extract(n, field, width) end
+
+--- Returns `n` with the bits `field` to `field + width - 1` replaced by `v`.
+---@param n integer
+---@param v integer
+---@param field integer
+---@param width? integer
+---@return integer
+function bit32.replace(n, v, field, width) end
+
+--- Returns `x` rotated `disp` bits to the left.
+---@param x integer
+---@param disp integer
+---@return integer
+function bit32.lrotate(x, disp) end
+
+--- Returns `x` shifted `disp` bits to the left.
+---@param x integer
+---@param disp integer
+---@return integer
+function bit32.lshift(x, disp) end
+
+--- Returns `x` rotated `disp` bits to the right.
+---@param x integer
+---@param disp integer
+---@return integer
+function bit32.rrotate(x, disp) end
+
+--- Returns `x` shifted `disp` bits to the right.
+---@param x integer
+---@param disp integer
+---@return integer
+function bit32.rshift(x, disp) end
@@ -0,0 +1,81 @@
+-- Definitions for Lua 5.1 and LuaJIT.
+
+
+--- Returns the environment of the function `f`, or of the function at the given stack level.
+---@param f? integer|function
+---@return table
+function getfenv(f) end
+
+--- Sets the environment of the function `f`, or of the function at the given stack level.
+---@param f integer|function
+---@param table table
+---@return function?
+function setfenv(f, table) end
+
+--- Loads a chunk from the pieces returned by `func`.
+---@param func function
+---@param chunkname? string
+---@return function?
+---@return string? errmsg
+function load(func, chunkname) end
+
+--- Loads a chunk from the file `filename`.
+---@param filename? string
+---@return function?
+---@return string? errmsg
+function loadfile(filename) end
+
+--- Loads a chunk from the string `s`.
+---@param s string
+---@param chunkname? string
+---@return function?
+---@return string? errmsg
+function loadstring(s, chunkname) end
+
+--- Creates a module.
+---@param name string
+---@param ... function
+function module(name, ...) end
+
+--- Returns the elements of `list` from `i` to `j`.
+---@generic T
+---@param list T[]
+---@param i? integer
+---@param j? integer
+---@return ...T
+function unpack(list, i, j) end
+
+--- Calls `f` in protected mode, with `err` as the error handler.
+---@param f function
+---@param err function
+---@return boolean success
+---@return ...any
+function xpcall(f, err) end
+
+--- Returns the natural logarithm of `x`.
+---@param x number
+---@return number
+function math.log(x) end
+
+--- Returns the base 10 logarithm of `x`.
+---@param x number
+---@return number
+function math.log10(x) end
+
+--- Returns `x` raised to the power of `y`.
+---@param x number
+---@param y number
+---@return number
+function math.pow(x, y) end
+
+---@type function[]
+package.loaders = {}
+
+--- Sets a metatable for `module` that inherits the global environment.
+---@param module table
+function package.seeall(module) end
+
+--- Returns the largest positive numerical index of `table`.
+---@param table table
+---@return integer
+function table.maxn(table) end
@@ -0,0 +1,62 @@
+-- Definitions for Lua 5.2 and later.
+
+--- Loads a chunk from a string or from the pieces returned by a function.
+---@param chunk string|function
+---@param chunkname? string
+---@param mode? "b"|"t"|"bt"
+---@param env? table
+---@return function?
+---@return string? errmsg
+function load(chunk, chunkname, mode, env) end
+
+--- Loads a chunk from the file `filename`.
+---@param filename? string
+---@param mode? "b"|"t"|"bt"
+---@param env? table
+---@return function?
+---@return string? errmsg
+function loadfile(filename, mode, env) end
+
+--- Returns the length of `v` without calling metamethods.
+---@param v table|string
+---@return integer
+function rawlen(v) end
+
+--- Calls `f` with the given arguments in protected mode, with `msgh` as the error handler.
+---@param f function
+---@param msgh function
+---@param ... any
+---@return boolean success
+---@return ...any
+function xpcall(f, msgh, ...) end
+
+--- Returns the logarithm of `x` in the given base, which defaults to e.
+---@param x number
+---@param base? number
+---@return number
+function math.log(x, base) end
+
+---@type function[]
+package.searchers = {}
+
+--- Searches for `name` in the given path.
+---@param name string
+---@param path string
+---@param sep? string
+---@param rep? string
+---@return string?
+---@return string? errmsg
+function package.searchpath(name, path, sep, rep) end
+
+--- Returns a table of its arguments, with the number of arguments in `n`.
+---@param ... any
+---@return table
+function table.pack(...) end
+
+--- Returns the elements of `list` from `i` to `j`.
+---@generic T
+---@param list T[]
+---@param i? integer
+---@param j? integer
+---@return ...T
+function table.unpack(list, i, j) end
@@ -0,0 +1,89 @@
+-- Definitions for Lua 5.3 and later.
+
+math.maxinteger = 9223372036854775807
+math.mininteger = -9223372036854775808
+
+--- Converts `x` to an integer, or returns nil if it is not representable.
+---@param x any
+---@return integer?
+function math.tointeger(x) end
+
+--- Returns whether `x` is an integer, a float, or not a number.
+---@param x any
+---@return "integer"|"float"|nil
+function math.type(x) end
+
+--- Returns whether `m` is less than `n` when compared as unsigned integers.
+---@param m integer
+---@param n integer
+---@return boolean
+function math.ult(m, n) end
+
+--- Returns whether the running coroutine can yield.
+---@return boolean
+function coroutine.isyieldable() end
+
+--- Returns a binary string of its arguments packed according to `fmt`.
+---@param fmt string
+---@param ... any
+---@return string
+function string.pack(fmt, ...) end
+
+--- Returns the size of a string packed according to `fmt`.
+---@param fmt string
+---@return integer
+function string.packsize(fmt) end
+
+--- Returns the values packed in `s` according to `fmt`, followed by the position after them.
+---@param fmt string
+---@param s string
+---@param pos? integer
+---@return ...any
+function string.unpack(fmt, s, pos) end
+
+--- Moves the elements of `a1` from `f` to `e` into `a2` starting at `t`, and returns `a2`.
+---@param a1 table
+---@param f integer
+---@param e integer
+---@param t integer
+---@param a2? table
+---@return table
+function table.move(a1, f, e, t, a2) end
+
+utf8 = {}
+
+utf8.charpattern = ""
+
+--- Returns a string of the characters with the given code points.
+---@param ... integer
+---@return string
+function utf8.char(...) end
+
+--- Returns an iterator over the positions and code points of the characters of `s`.
+---@param s string
+---@return (fun(s: string, i: integer): integer, integer)
+---@return string
+---@return integer
+function utf8.codes(s) end
+
+--- Returns the code points of the characters from `i` to `j`.
+---@param s string
+---@param i? integer
+---@param j? integer
+---@return ...integer
+function utf8.codepoint(s, i, j) end
+
+--- Returns the number of characters from `i` to `j`, or nil and the position of the first invalid byte.
+---@param s string
+---@param i? integer
+---@param j? integer
+---@return integer?
+---@return integer? position
+function utf8.len(s, i, j) end
+
+--- Returns the byte position of the `n`-th character.
+---@param s string
+---@param n integer
+---@param i? integer
+---@return integer?
+function utf8.offset(s, n, i) end
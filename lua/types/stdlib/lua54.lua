@@ -0,0 +1,12 @@
+-- Definitions for Lua 5.4.
+
+--- Emits a warning made of its arguments.
+---@param message string
+---@param ... string
+function warn(message, ...) end
+
+--- Closes the coroutine `co`, and returns whether it had no errors.
+---@param co any
+---@return boolean
+---@return any errorobject
+function coroutine.close(co) end
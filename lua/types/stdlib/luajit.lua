@@ -0,0 +1,99 @@
+-- Definitions for the LuaJIT extensions.
+
+bit = {}
+
+--- Returns the bitwise and of its arguments.
+---@param x integer
+---@param ... integer
+---@return integer
+function bit.band(x, ...) end
+
+--- Returns the bitwise negation of `x`.
+---@param x integer
+---@return integer
+function bit.bnot(x) end
+
+--- Returns the bitwise or of its arguments.
+---@param x integer
+---@param ... integer
+---@return integer
+function bit.bor(x, ...) end
+
+--- Returns the bitwise exclusive or of its arguments.
+---@param x integer
+---@param ... integer
+---@return integer
+function bit.bxor(x, ...) end
+
+--- Swaps the bytes of `x`.
+---@param x integer
+---@return integer
+function bit.bswap(x) end
+
+--- Returns `x` shifted arithmetically by `n` bits to the right.
+---@param x integer
+---@param n integer
+---@return integer
+function bit.arshift(x, n) end
+
+--- Returns `x` shifted `n` bits to the left.
+---@param x integer
+---@param n integer
+---@return integer
+function bit.lshift(x, n) end
+
+--- Returns `x` rotated `n` bits to the left.
+---@param x integer
+---@param n integer
+---@return integer
+function bit.rol(x, n) end
+
+--- Returns `x` rotated `n` bits to the right.
+---@param x integer
+---@param n integer
+---@return integer
+function bit.ror(x, n) end
+
+--- Returns `x` shifted `n` bits to the right.
+---@param x integer
+---@param n integer
+---@return integer
+function bit.rshift(x, n) end
+
+--- Converts `x` to a 32-bit integer.
+---@param x number
+---@return integer
+function bit.tobit(x) end
+
+--- Converts `x` to a hexadecimal string of `n` digits.
+---@param x integer
+---@param n? integer
+---@return string
+function bit.tohex(x, n) end
+
+jit = {}
+
+jit.arch = ""
+jit.os = ""
+jit.version = ""
+jit.version_num = 0
+
+--- Turns the JIT compiler on, for a function or for the whole program.
+---@param func? function|boolean
+---@param recursive? boolean
+function jit.on(func, recursive) end
+
+--- Turns the JIT compiler off, for a function or for the whole program.
+---@param func? function|boolean
+---@param recursive? boolean
+function jit.off(func, recursive) end
+
+--- Flushes the compiled code.
+---@param func? function|boolean
+---@param recursive? boolean
+function jit.flush(func, recursive) end
+
+--- Returns whether the JIT compiler is on, followed by the CPU features and optimizations.
+---@return boolean
+---@return ...string
+function jit.status() end
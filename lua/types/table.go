@@ -0,0 +1,300 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// resolveTableType infers the type of a table constructor. Fields with names
+// become record fields, positional fields become an array indexer, and other
+// keys become a map indexer.
+func (e *Environment) resolveTableType(lit *ast.TableLiteral) *Table {
+	tbl := &Table{}
+	e.ownTables[tbl] = true
+	var keys, values []Type
+	for _, pair := range lit.Fields.Pairs {
+		switch field := pair.Node.(type) {
+		case *ast.TableArrayField:
+			keys = append(keys, &Number{})
+			values = append(values, typeOrUnknown(e.resolveExprType(field.Expr)))
+		case *ast.TableSimpleKeyField:
+			typ := typeOrUnknown(e.resolveExprType(field.Expr))
+			e.addType(&field.Name, typ)
+			tbl.setField(field.Name.Token.Literal, field, typ)
+		case *ast.TableExpressionKeyField:
+			keyTyp := typeOrUnknown(e.resolveExprType(field.Name))
+			typ := typeOrUnknown(e.resolveExprType(field.Expr))
+			if lit, ok := field.Name.(*ast.StringLiteral); ok {
+				if name, ok := stringValue(lit); ok {
+					tbl.setField(name, field, typ)
+					continue
+				}
+			}
+			keys = append(keys, keyTyp)
+			values = append(values, typ)
+		}
+	}
+	if len(values) > 0 {
+		tbl.Key = NewUnion(keys...)
+		tbl.Value = NewUnion(values...)
+	}
+	return tbl
+}
+
+// setField adds a field to the table, replacing an earlier field with the
+// same name.
+func (t *Table) setField(name string, def ast.Node, typ Type) {
+	if field := t.field(name); field != nil {
+		field.Def = def
+		field.Type = typ
+		return
+	}
+	t.Fields = append(t.Fields, NameAndType{Name: name, Def: def, Type: typ})
+}
+
+// merge adds the fields, indexer and metatable of the table that a class is
+// created from. Annotated fields take precedence.
+func (t *Table) merge(value Type) {
+	tbl, ok := value.(*Table)
+	if !ok || tbl == t {
+		return
+	}
+	for _, field := range tbl.Fields {
+		if t.field(field.Name) == nil {
+			t.Fields = append(t.Fields, field)
+		}
+	}
+	if t.Value == nil {
+		t.Key, t.Value = tbl.Key, tbl.Value
+	}
+	if t.Metatable == nil {
+		t.Metatable = tbl.Metatable
+	}
+}
+
+// lookup returns the field of the table, or the inherited field of its
+// class, its parents or its metatable's __index table, or nil if there is
+// none. The fields of a generic class have the type arguments of the
+// instance.
+func (t *Table) lookup(name string) *NameAndType {
+	return t.lookupSeen(name, map[*Table]bool{})
+}
+
+func (t *Table) lookupSeen(name string, seen map[*Table]bool) *NameAndType {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	if field := t.field(name); field != nil {
+		return field
+	}
+	if t.Generic != nil {
+		if field := t.Generic.lookupSeen(name, seen); field != nil {
+			return &NameAndType{Name: field.Name, Def: field.Def, Type: substitute(field.Type, t.typeBindings())}
+		}
+	}
+	for _, parent := range t.Parents {
+		if field := parent.lookupSeen(name, seen); field != nil {
+			return field
+		}
+	}
+	if index := t.index(); index != nil {
+		return index.lookupSeen(name, seen)
+	}
+	return nil
+}
+
+// index returns the __index table of the table's metatable, or nil if it has
+// none.
+func (t *Table) index() *Table {
+	if t.Metatable == nil {
+		return nil
+	}
+	if field := t.Metatable.field("__index"); field != nil {
+		if tbl, ok := field.Type.(*Table); ok {
+			return tbl
+		}
+	}
+	return nil
+}
+
+// inherits returns whether the table is other, or inherits from it.
+func (t *Table) inherits(other *Table) bool {
+	found := false
+	t.walkAncestors(func(ancestor *Table) bool {
+		found = ancestor == other || sameInstance(ancestor, other, map[[2]Type]bool{})
+		return !found
+	})
+	return found
+}
+
+// walkAncestors calls fn with the table and every table that it inherits
+// from, nearest first, until fn returns false.
+func (t *Table) walkAncestors(fn func(*Table) bool) {
+	seen := map[*Table]bool{}
+	var walk func(*Table) bool
+	walk = func(t *Table) bool {
+		if seen[t] {
+			return true
+		}
+		seen[t] = true
+		if !fn(t) {
+			return false
+		}
+		if t.Generic != nil && !walk(t.Generic) {
+			return false
+		}
+		for _, parent := range t.Parents {
+			if !walk(parent) {
+				return false
+			}
+		}
+		if index := t.index(); index != nil {
+			return walk(index)
+		}
+		return true
+	}
+	walk(t)
+}
+
+// indexName returns the field name of an index expression, if it indexes
+// with a name or a string literal.
+// findAssignedFields finds the fields that are assigned through each local
+// variable anywhere in the file. Functions can use them before the
+// assignment is resolved, since they are called later.
+func (e *Environment) findAssignedFields() {
+	e.assignedFields = map[*ast.Identifier]map[string]bool{}
+	add := func(target ast.Node) {
+		expr, ok := target.(*ast.IndexExpression)
+		if !ok {
+			return
+		}
+		prefix, ok := expr.Prefix.(*ast.Identifier)
+		if !ok {
+			return
+		}
+		binding := e.Scopes.Uses[prefix]
+		key, ok := indexName(expr)
+		if binding == nil || !ok {
+			return
+		}
+		if e.assignedFields[binding.Ident] == nil {
+			e.assignedFields[binding.Ident] = map[string]bool{}
+		}
+		e.assignedFields[binding.Ident][key] = true
+	}
+	ast.Walk(&e.file.Block, func(node ast.Node) bool {
+		switch stmt := node.(type) {
+		case *ast.AssignmentStatement:
+			for _, pair := range stmt.Vars.Pairs {
+				add(pair.Node)
+			}
+		case *ast.FunctionStatement:
+			add(stmt.Name)
+		}
+		return true
+	})
+}
+
+// isAssignedLater returns true if the index is in a function, and the field
+// is assigned through a local variable that holds the table, such as the
+// module table of a method's self.
+func (e *Environment) isAssignedLater(tbl *Table, key string) bool {
+	if len(e.functions) == 0 {
+		return false
+	}
+	for def, fields := range e.assignedFields {
+		if fields[key] && e.Types[def] == tbl {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveUnionIndexType types indexing a value that is one of several types.
+// The field is looked up in each of them.
+func (e *Environment) resolveUnionIndexType(expr *ast.IndexExpression, union *Union) Type {
+	key, named := indexName(expr)
+	var keyTyp Type
+	if !named {
+		keyTyp = typeOrUnknown(e.resolveExprType(expr.Inner))
+	}
+	types := []Type{}
+	for _, member := range union.Types {
+		switch member.(type) {
+		case *Any, *Unknown:
+			return e.addType(expr, &Unknown{})
+		case *String:
+			member = e.stringLibrary(member)
+		}
+		tbl, ok := member.(*Table)
+		if !ok {
+			e.addError(indexNonTable, expr.Inner, "Attempting to index '%s', which may be a non-table", union)
+			return nil
+		}
+		if !named {
+			if tbl.Value == nil {
+				return e.addType(expr, &Unknown{})
+			}
+			if !IsAssignable(keyTyp, tbl.Key) {
+				e.addError(indexTypeMismatch, expr.Inner, "Cannot index '%s' with '%s'", tbl, keyTyp)
+			}
+			types = append(types, tbl.Value)
+			continue
+		}
+		if field := tbl.lookup(key); field != nil {
+			types = append(types, typeOrUnknown(field.Type))
+		} else if tbl.Value != nil && IsAssignable(&String{}, tbl.Key) {
+			types = append(types, tbl.Value)
+		} else {
+			e.addError(undefinedField, expr.Inner, "Unknown field '%s' in '%s'", key, tbl)
+			return nil
+		}
+	}
+	typ := e.addType(expr, NewUnion(types...))
+	if named {
+		e.addType(expr.Inner, typ)
+	}
+	return typ
+}
+
+// stringLibrary returns the string library table, through which strings are
+// indexed, or typ if it is not defined.
+func (e *Environment) stringLibrary(typ Type) Type {
+	if library, ok := e.GlobalTypes["string"].(*Table); ok {
+		return library
+	}
+	return typ
+}
+
+func indexName(expr *ast.IndexExpression) (string, bool) {
+	switch inner := expr.Inner.(type) {
+	case *ast.Identifier:
+		if expr.LeftIndexer.Type() != token.LBRACK {
+			return inner.Token.Literal, true
+		}
+	case *ast.StringLiteral:
+		return stringValue(inner)
+	}
+	return "", false
+}
+
+// stringValue returns the contents of a string literal, if it can be known
+// without interpreting escape sequences.
+func stringValue(lit *ast.StringLiteral) (string, bool) {
+	literal := lit.Token.Literal
+	if lit.Token.Type == token.RAWSTRING {
+		// [==[contents]==]
+		level := strings.IndexByte(literal[1:], '[') + 2
+		if level < 2 || len(literal) < level*2 {
+			return "", false
+		}
+		return literal[level : len(literal)-level], true
+	}
+	if len(literal) < 2 || strings.ContainsRune(literal, '\\') {
+		return "", false
+	}
+	return literal[1 : len(literal)-1], true
+}
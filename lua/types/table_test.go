@@ -0,0 +1,156 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableInference(t *testing.T) {
+	src := `local record = { first = 1, ["second"] = "two", third = true }
+local array = { 1, 2, 3 }
+local mixed = { 1, "two" }
+local key = "k"
+local map = { [key] = "a", [key .. "2"] = "b" }
+local both = { 1, name = "a" }
+local empty = {}
+
+local a = record.first
+local b = record["second"]
+local c = array[1]
+local d = mixed[2]
+local e = map[key]
+
+---@type table<string, boolean>
+local flags
+local f = flags.anything
+---@type string[]
+local names
+local g = names[1]
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, "local "+word)+len("local "))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "{first: number, second: string, third: boolean}", typeAt("record ="))
+	assert.Equal(t, "number[]", typeAt("array ="))
+	assert.Equal(t, "(number|string)[]", typeAt("mixed ="))
+	assert.Equal(t, "table<string, string>", typeAt("map ="))
+	assert.Equal(t, "{name: string, [number]: number}", typeAt("both ="))
+	assert.Equal(t, "{}", typeAt("empty ="))
+	assert.Equal(t, "number", env.Types[identAt(t, env, strings.Index(src, "first ="))].String())
+
+	assert.Equal(t, "number", typeAt("a ="))
+	assert.Equal(t, "string", typeAt("b ="))
+	assert.Equal(t, "number", typeAt("c ="))
+	assert.Equal(t, "number|string", typeAt("d ="))
+	assert.Equal(t, "string", typeAt("e ="))
+	assert.Equal(t, "boolean", typeAt("f ="))
+	assert.Equal(t, "string", typeAt("g ="))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestTableIndexErrors(t *testing.T) {
+	env := newTestEnvironment(`local array = { 1, 2 }
+local a = array.name
+local b = array["x"]
+local record = { first = 1 }
+local c = record.second
+`)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message)
+	}
+	assert.Equal(t, []string{
+		"Unknown field 'name'",
+		"Unknown field 'x'",
+		"Unknown field 'second'",
+	}, errors)
+}
+
+func TestTableAssignability(t *testing.T) {
+	numbers := &Table{Key: &Number{}, Value: &Number{}}
+	strings := &Table{Key: &Number{}, Value: &String{}}
+	record := &Table{Fields: []NameAndType{{Name: "a", Type: &Number{}}}}
+	dict := &Table{Key: &String{}, Value: &Number{}}
+
+	assert.True(t, IsAssignable(numbers, numbers))
+	assert.False(t, IsAssignable(numbers, strings))
+	assert.True(t, IsAssignable(&Table{}, numbers))
+	assert.False(t, IsAssignable(record, numbers))
+	assert.True(t, IsAssignable(record, dict))
+	assert.True(t, IsAssignable(numbers, &Table{}))
+}
+
+func TestUnionIndex(t *testing.T) {
+	src := `---@class Cat
+---@field name string
+---@field lives number
+local Cat = {}
+
+---@class Dog
+---@field name string
+local Dog = {}
+
+---@param pet Cat|Dog
+---@param maybe Cat?
+---@param value Cat|number
+local function f(pet, maybe, value)
+  local name = pet.name
+  local lives = pet.lives
+  local other = maybe.name
+  local none = nil
+  local x = none.name
+  local y = value.name
+  if maybe then
+    local z = maybe.lives
+  end
+end
+`
+	env := newTestEnvironment(src)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Code+": "+err.Message)
+	}
+	assert.Equal(t, []string{
+		"undefined-field: Unknown field 'lives' in 'Dog'",
+		"index-nil: Attempting to index a value that may be nil",
+		"index-nil: Attempting to index a nil value",
+		"index-non-table: Attempting to index 'Cat|number', which may be a non-table",
+	}, errors)
+	assert.Equal(t, "string", env.Types[identAt(t, env, strings.Index(src, "name ="))].String())
+	assert.Equal(t, "string", env.Types[identAt(t, env, strings.Index(src, "other ="))].String())
+	assert.Equal(t, "number", env.Types[identAt(t, env, strings.Index(src, "z ="))].String())
+}
+
+func TestForwardFieldReferences(t *testing.T) {
+	env := newTestEnvironment(`local M = {}
+function M.a()
+  return M.b() + M.c
+end
+function M:d()
+  return self:b(), self.e, M.missing
+end
+print(M.late)
+function M.b() return 1 end
+M.c = 2
+M.late = 3
+return M
+`)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message)
+	}
+	// Functions run after the module is built, but the file's top level runs
+	// in order
+	assert.Equal(t, []string{
+		"Unknown field 'e'",
+		"Unknown field 'missing'",
+		"Unknown field 'late'",
+	}, errors)
+}
@@ -16,44 +16,89 @@ type (
 	Any      struct{}
 	Boolean  struct{}
 	Function struct {
-		Params []NameAndType
-		Return Type
+		TypeParams []*TypeParam
+		Params     []NameAndType
+		Vararg     Type   // The type of `...`, or nil if the function is not variadic
+		Returns    *Tuple // nil if unknown
 	}
+	Nil    struct{}
 	Number struct{}
 	String struct{}
-	Table  struct {
-		Fields []NameAndType
+	// Table is a record with named fields, and optionally an indexer for
+	// other keys. An array has an indexer with number keys. A class is a
+	// named table that inherits the fields of its parents. A generic class
+	// has type parameters, and is used through instances that have type
+	// arguments for them.
+	Table struct {
+		Name       string // The class name, or empty
+		TypeParams []*TypeParam
+		Generic    *Table // The class that this is an instance of, or nil
+		TypeArgs   []Type // The type arguments of an instance
+		Parents    []*Table
+		Fields     []NameAndType
+		Key        Type // nil if the table has no indexer
+		Value      Type
+		Metatable  *Table // nil if unknown
+	}
+	// TypeParam is a type parameter of a generic function or class.
+	TypeParam struct {
+		Name       string
+		Constraint Type // nil if unconstrained
+	}
+	// Union is one of several types. Use NewUnion to create a normalized union.
+	Union struct {
+		Types []Type
 	}
 	Unknown struct{}
 )
 
-func (a *Any) isType()      {}
-func (b *Boolean) isType()  {}
-func (f *Function) isType() {}
-func (n *Number) isType()   {}
-func (s *String) isType()   {}
-func (t *Table) isType()    {}
-func (u *Unknown) isType()  {}
+func (a *Any) isType()       {}
+func (b *Boolean) isType()   {}
+func (f *Function) isType()  {}
+func (n *Nil) isType()       {}
+func (n *Number) isType()    {}
+func (s *String) isType()    {}
+func (t *Table) isType()     {}
+func (t *TypeParam) isType() {}
+func (u *Union) isType()     {}
+func (u *Unknown) isType()   {}
 
 func (b *Any) String() string     { return "any" }
 func (b *Boolean) String() string { return "boolean" }
 func (f *Function) String() string {
-	output := "function("
+	params := []string{}
 	for _, param := range f.Params {
-		output = output + param.String() + ", "
+		params = append(params, param.String())
 	}
-	if len(f.Params) > 0 {
-		output = output[0 : len(output)-2]
+	if f.Vararg != nil {
+		params = append(params, "...: "+f.Vararg.String())
 	}
-	output = output + ")"
-	if f.Return != nil {
-		output = output + " → " + f.Return.String()
+	output := "function" + typeParamsString(f.TypeParams) + "(" + strings.Join(params, ", ") + ")"
+	if f.Returns != nil && (len(f.Returns.Types) > 0 || f.Returns.Rest != nil) {
+		output = output + " → " + f.Returns.String()
 	}
 	return output
 }
+func (n *Nil) String() string    { return "nil" }
 func (n *Number) String() string { return "number" }
 func (s *String) String() string { return "string" }
 func (t *Table) String() string {
+	if t.Name != "" {
+		if t.Generic != nil {
+			args := make([]string, len(t.TypeArgs))
+			for i, arg := range t.TypeArgs {
+				args[i] = arg.String()
+			}
+			return t.Name + "<" + strings.Join(args, ", ") + ">"
+		}
+		return t.Name + typeParamsString(t.TypeParams)
+	}
+	if len(t.Fields) == 0 && t.Value != nil {
+		if _, ok := t.Key.(*Number); ok {
+			return parenthesize(t.Value) + "[]"
+		}
+		return fmt.Sprintf("table<%s, %s>", t.Key, t.Value)
+	}
 	var sb strings.Builder
 	sb.WriteByte('{')
 	for i := 0; i < len(t.Fields); i++ {
@@ -62,11 +107,63 @@ func (t *Table) String() string {
 		}
 		fmt.Fprintf(&sb, "%s", &t.Fields[i])
 	}
+	if t.Value != nil {
+		if len(t.Fields) > 0 {
+			fmt.Fprint(&sb, ", ")
+		}
+		fmt.Fprintf(&sb, "[%s]: %s", t.Key, t.Value)
+	}
 	sb.WriteByte('}')
 
 	return sb.String()
 }
-func (u *Unknown) String() string { return "unknown" }
+func (u *Union) String() string {
+	if inner := u.optionalOf(); inner != nil {
+		return parenthesize(inner) + "?"
+	}
+	parts := make([]string, len(u.Types))
+	for i, typ := range u.Types {
+		parts[i] = parenthesize(typ)
+	}
+	return strings.Join(parts, "|")
+}
+func (t *TypeParam) String() string { return t.Name }
+func (u *Unknown) String() string   { return "unknown" }
+
+func typeParamsString(params []*TypeParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Name
+	}
+	return "<" + strings.Join(names, ", ") + ">"
+}
+
+// optionalOf returns T if the union is `T|nil`.
+func (u *Union) optionalOf() Type {
+	if len(u.Types) != 2 {
+		return nil
+	}
+	if _, ok := u.Types[1].(*Nil); ok {
+		return u.Types[0]
+	}
+	if _, ok := u.Types[0].(*Nil); ok {
+		return u.Types[1]
+	}
+	return nil
+}
+
+// parenthesize wraps function types, whose return type would otherwise
+// swallow the rest of a union, and unions inside of arrays.
+func parenthesize(typ Type) string {
+	switch typ.(type) {
+	case *Function, *Union:
+		return "(" + typ.String() + ")"
+	}
+	return typ.String()
+}
 
 type NameAndType struct {
 	Name string
@@ -81,3 +178,53 @@ func (n *NameAndType) String() string {
 	}
 	return fmt.Sprintf("%s: %s", n.Name, n.Type)
 }
+
+// Tuple is a list of values, such as the arguments or results of a call. If
+// Rest is set, any number of values of that type follow.
+type Tuple struct {
+	Types []Type
+	Rest  Type
+}
+
+// NewTuple returns a tuple of the given types.
+func NewTuple(types ...Type) *Tuple {
+	return &Tuple{Types: types}
+}
+
+// At returns the type of the i-th value. Missing values are nil.
+func (t *Tuple) At(i int) Type {
+	if i < len(t.Types) {
+		return t.Types[i]
+	}
+	switch t.Rest.(type) {
+	case nil:
+		return &Nil{}
+	case *Any, *Unknown:
+		return t.Rest
+	}
+	// There may be fewer values
+	return NewOptional(t.Rest)
+}
+
+// Len returns the number of values, or -1 if it is not known.
+func (t *Tuple) Len() int {
+	if t.Rest != nil {
+		return -1
+	}
+	return len(t.Types)
+}
+
+func (t *Tuple) String() string {
+	parts := []string{}
+	for _, typ := range t.Types {
+		if _, ok := typ.(*Function); ok {
+			parts = append(parts, parenthesize(typ))
+		} else {
+			parts = append(parts, typ.String())
+		}
+	}
+	if t.Rest != nil {
+		parts = append(parts, "..."+parenthesize(t.Rest))
+	}
+	return strings.Join(parts, ", ")
+}
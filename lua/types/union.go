@@ -0,0 +1,94 @@
+package types
+
+// NewUnion returns the union of the given types. Nested unions are flattened
+// and duplicates are removed. Any absorbs every other type. A union of a
+// single type is that type.
+func NewUnion(types ...Type) Type {
+	members := []Type{}
+	var add func(typ Type)
+	add = func(typ Type) {
+		switch typ := typ.(type) {
+		case nil:
+			return
+		case *Union:
+			for _, member := range typ.Types {
+				add(member)
+			}
+			return
+		}
+		for _, member := range members {
+			if Equal(member, typ) {
+				return
+			}
+		}
+		members = append(members, typ)
+	}
+	for _, typ := range types {
+		add(typ)
+	}
+	for _, member := range members {
+		if _, ok := member.(*Any); ok {
+			return member
+		}
+	}
+	switch len(members) {
+	case 0:
+		return &Unknown{}
+	case 1:
+		return members[0]
+	}
+	return &Union{Types: members}
+}
+
+// NewOptional returns `typ|nil`.
+func NewOptional(typ Type) Type {
+	return NewUnion(typ, &Nil{})
+}
+
+// Members returns the members of a union, or the type itself.
+func Members(typ Type) []Type {
+	if union, ok := typ.(*Union); ok {
+		return union.Types
+	}
+	return []Type{typ}
+}
+
+// canBeNil returns true if typ is nil or a union with nil.
+func canBeNil(typ Type) bool {
+	for _, member := range Members(typ) {
+		if _, ok := member.(*Nil); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// truthy returns the part of typ that can be truthy, or nil if there is none.
+func truthy(typ Type) Type {
+	members := []Type{}
+	for _, member := range Members(typ) {
+		if _, ok := member.(*Nil); !ok {
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	return NewUnion(members...)
+}
+
+// falsy returns the part of typ that can be falsy, or nil if there is none.
+// Unknown types are assumed to be truthy.
+func falsy(typ Type) Type {
+	members := []Type{}
+	for _, member := range Members(typ) {
+		switch member.(type) {
+		case *Boolean, *Nil, *Any:
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	return NewUnion(members...)
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUnion(t *testing.T) {
+	tests := []struct {
+		types    []Type
+		expected string
+	}{
+		{[]Type{&Number{}}, "number"},
+		{[]Type{&Number{}, &String{}}, "number|string"},
+		{[]Type{&Number{}, &Number{}}, "number"},
+		{[]Type{&Number{}, NewUnion(&String{}, &Number{})}, "number|string"},
+		{[]Type{&String{}, &Any{}}, "any"},
+		{[]Type{&String{}, &Nil{}}, "string?"},
+		{[]Type{&Nil{}, &Boolean{}, &String{}}, "nil|boolean|string"},
+		{[]Type{&Function{Returns: NewTuple(&Number{})}, &Nil{}}, "(function() → number)?"},
+		{[]Type{}, "unknown"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, NewUnion(test.types...).String())
+	}
+	assert.Equal(t, "number?", NewOptional(NewOptional(&Number{})).String())
+}
+
+func TestUnionInference(t *testing.T) {
+	src := `local test = 1 > 0
+local a = test and 1 or "a"
+local b = nil
+local c = b or 1
+local d = b and 1
+
+---@param x string|number|nil
+---@param y boolean?
+local function f(x, y)
+  local e = x
+  local g = y
+end
+
+---@param n number
+local function h(n)
+  local flag = n > 1
+  local i = (flag and 5 or 6) + 1
+  local j = flag and "yes" or nil
+  local k = flag and n or flag
+end
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "number|string", typeAt("a ="))
+	assert.Equal(t, "nil", typeAt("b ="))
+	assert.Equal(t, "number", typeAt("c ="))
+	assert.Equal(t, "nil", typeAt("d ="))
+	assert.Equal(t, "string|number|nil", typeAt("e ="))
+	assert.Equal(t, "boolean?", typeAt("g ="))
+	assert.Equal(t, "number", typeAt("i ="))
+	assert.Equal(t, "string?", typeAt("j ="))
+	assert.Equal(t, "number|boolean", typeAt("k ="))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
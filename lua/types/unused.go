@@ -0,0 +1,95 @@
+package types
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/raiguard/luapls/lua/ast"
+)
+
+// checkUnused reports local variables that are never read and labels that no
+// goto targets. Names that start with an underscore are unused on purpose.
+func (e *Environment) checkUnused() {
+	if e.isLibrary {
+		return
+	}
+	for _, ident := range e.Scopes.Order {
+		binding := e.Scopes.Uses[ident]
+		if binding == nil || binding.Ident != ident || strings.HasPrefix(binding.Name, "_") || e.isRead(binding) {
+			continue
+		}
+		var err *ast.Error
+		switch binding.Kind {
+		case LocalBinding:
+			if e.isClosed(ident) {
+				continue
+			}
+			err = e.addError(unusedLocal, ident, "Unused local '%s'", binding.Name)
+		case ForBinding:
+			err = e.addError(unusedLocal, ident, "Unused local '%s'", binding.Name)
+		case LocalFunctionBinding:
+			err = e.addError(unusedFunction, ident, "Unused local function '%s'", binding.Name)
+		case ParamBinding:
+			err = e.addError(unusedParameter, ident, "Unused parameter '%s'", binding.Name)
+		default:
+			continue
+		}
+		err.Tags = []ast.Tag{ast.TagUnnecessary}
+	}
+
+	labels := []*ast.LabelStatement{}
+	for label, targeted := range e.labels {
+		if !targeted && label.Name != nil {
+			labels = append(labels, label)
+		}
+	}
+	slices.SortFunc(labels, func(a, b *ast.LabelStatement) int { return a.Pos() - b.Pos() })
+	for _, label := range labels {
+		err := e.addError(unusedLabel, label.Name, "Unused label '%s'", label.Name.Token.Literal)
+		err.Tags = []ast.Tag{ast.TagUnnecessary}
+	}
+}
+
+// isRead returns true if the variable is read anywhere. A local function that
+// only calls itself is not read.
+func (e *Environment) isRead(binding *Binding) bool {
+	var body *ast.Block
+	if stmt, ok := e.declaration(binding.Ident).(*ast.FunctionStatement); ok && binding.Kind == LocalFunctionBinding {
+		body = &stmt.Body
+	}
+	for _, ref := range e.References[binding.Ident] {
+		if ref.Write {
+			continue
+		}
+		if body == nil || ref.Ident.Pos() < body.Pos() || ref.Ident.Pos() >= body.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// isClosed returns true if the local variable has the `<close>` attribute,
+// which calls its __close metamethod when it goes out of scope.
+func (e *Environment) isClosed(ident *ast.Identifier) bool {
+	stmt, ok := e.declaration(ident).(*ast.LocalStatement)
+	if !ok {
+		return false
+	}
+	for i, pair := range stmt.Names.Pairs {
+		if pair.Node == ident && i < len(stmt.Attribs) && stmt.Attribs[i] != nil && stmt.Attribs[i].Name != nil {
+			return stmt.Attribs[i].Name.Token.Literal == "close"
+		}
+	}
+	return false
+}
+
+// declaration returns the statement that contains the identifier.
+func (e *Environment) declaration(ident *ast.Identifier) ast.Statement {
+	parents := ast.GetNode(&e.file.Block, ident.Pos()).Parents
+	for i := len(parents) - 1; i >= 0; i-- {
+		if stmt, ok := parents[i].(ast.Statement); ok {
+			return stmt
+		}
+	}
+	return nil
+}
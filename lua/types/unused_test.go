@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnused(t *testing.T) {
+	env := newTestEnvironment(`local used, unused = 1, 2
+local assigned = 1
+assigned = 2
+local _ignored = 3
+local handle <close> = nil
+local function recurse(n) return recurse(n - 1) end
+local function callback(_event, data) end
+local function read(a, b) return a end
+for i = 1, 10 do print(used) end
+for _, v in ipairs({}) do end
+::skip::
+::top::
+print(read, callback)
+goto top
+`)
+	errors := []string{}
+	for _, err := range env.Errors {
+		assert.Equal(t, ast.SeverityHint, err.Severity)
+		assert.Equal(t, []ast.Tag{ast.TagUnnecessary}, err.Tags)
+		errors = append(errors, err.Code+": "+err.Message)
+	}
+	assert.Equal(t, []string{
+		"unused-local: Unused local 'unused'",
+		"unused-local: Unused local 'assigned'",
+		"unused-function: Unused local function 'recurse'",
+		"unused-parameter: Unused parameter 'data'",
+		"unused-parameter: Unused parameter 'b'",
+		"unused-local: Unused local 'i'",
+		"unused-local: Unused local 'v'",
+		"unused-label: Unused label 'skip'",
+	}, errors)
+}
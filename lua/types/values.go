@@ -0,0 +1,226 @@
+package types
+
+import (
+	"slices"
+
+	"github.com/raiguard/luapls/lua/ast"
+	"github.com/raiguard/luapls/lua/token"
+)
+
+// resolveExprValues returns the values of an expression. Calls and `...` can
+// have any number of values, other expressions have one.
+func (e *Environment) resolveExprValues(expr ast.Expression) *Tuple {
+	switch expr := expr.(type) {
+	case *ast.FunctionCall:
+		e.pushNode(expr)
+		defer e.popNode()
+		values := e.resolveFunctionCallValues(expr)
+		e.addType(expr, values.At(0))
+		return values
+	case *ast.Vararg:
+		var typ Type = &Unknown{}
+		if len(e.functions) > 0 {
+			typ = e.functions[len(e.functions)-1].typ.Vararg
+			if typ == nil {
+				e.addError(invalidVararg, expr, "Cannot use '...' outside of a variadic function")
+				typ = &Unknown{}
+			}
+		}
+		e.addType(expr, typ)
+		return &Tuple{Rest: typ}
+	}
+	return NewTuple(typeOrUnknown(e.resolveExprType(expr)))
+}
+
+// resolveExprListValues returns the values of an expression list. Every
+// expression is truncated to one value, except for the last.
+func (e *Environment) resolveExprListValues(list *ast.Punctuated[ast.Expression]) *Tuple {
+	values := &Tuple{}
+	for i, pair := range list.Pairs {
+		if i < len(list.Pairs)-1 {
+			values.Types = append(values.Types, typeOrUnknown(e.resolveExprType(pair.Node)))
+			continue
+		}
+		last := e.resolveExprValues(pair.Node)
+		values.Types = append(values.Types, last.Types...)
+		values.Rest = last.Rest
+	}
+	return values
+}
+
+// resolveFunctionCallValues checks the arguments of a call and returns its
+// results.
+func (e *Environment) resolveFunctionCallValues(fc *ast.FunctionCall) *Tuple {
+	typ := e.resolveExprType(fc.Name)
+	args := e.resolveExprListValues(&fc.Args)
+	// A method call passes the table as the first argument
+	argNodes := make([]ast.Node, len(fc.Args.Pairs))
+	for i, pair := range fc.Args.Pairs {
+		argNodes[i] = pair.Node
+	}
+	if name, ok := fc.Name.(*ast.IndexExpression); ok && name.LeftIndexer.Type() == token.COLON {
+		args = &Tuple{Types: append([]Type{typeOrUnknown(e.Types[name.Prefix])}, args.Types...), Rest: args.Rest}
+		argNodes = append([]ast.Node{name.Prefix}, argNodes...)
+	}
+	function, ok := typ.(*Function)
+	if !ok {
+		switch typ.(type) {
+		case nil, *Any, *Unknown:
+		default:
+			e.addError(callNonCallable, fc, "'%s' is not a function", fc.Name)
+		}
+		return &Tuple{Rest: &Unknown{}}
+	}
+	if len(function.TypeParams) > 0 {
+		function = instantiateFunction(function, args)
+		// Hover shows the instantiated signature
+		e.addType(fc.Name, function)
+		if name, ok := fc.Name.(*ast.IndexExpression); ok {
+			e.addType(name.Inner, function)
+		}
+	}
+
+	// argNode returns the argument expression that the i-th value came from
+	argNode := func(i int) ast.Node {
+		if len(argNodes) == 0 {
+			return fc
+		}
+		return argNodes[min(i, len(argNodes)-1)]
+	}
+	for i, param := range function.Params {
+		paramTyp := typeOrUnknown(param.Type)
+		if i >= len(args.Types) && args.Rest == nil {
+			if !IsAssignable(&Nil{}, paramTyp) {
+				e.addError(missingParameter, fc, "Too few function parameters, expected %v, got %v", len(function.Params), len(args.Types))
+				break
+			}
+			continue
+		}
+		if argTyp := args.At(i); !IsAssignable(argTyp, paramTyp) {
+			e.addError(paramTypeMismatch, argNode(i), "Cannot use '%s' as '%s' in argument.", argTyp, paramTyp)
+		}
+	}
+	for i := len(function.Params); i < len(args.Types); i++ {
+		if function.Vararg == nil {
+			e.addError(redundantParameter, argNode(i), "Unused parameter")
+			break
+		}
+		if !IsAssignable(args.Types[i], function.Vararg) {
+			e.addError(paramTypeMismatch, argNode(i), "Cannot use '%s' as '%s' in argument.", args.Types[i], function.Vararg)
+		}
+	}
+
+	if values := e.resolveBuiltinCall(fc, args); values != nil {
+		return values
+	}
+	if function.Returns == nil {
+		return &Tuple{Rest: &Unknown{}}
+	}
+	return function.Returns
+}
+
+// resolveBuiltinCall returns the results of a call to a standard library
+// function whose results depend on its arguments, or nil if it is not one.
+func (e *Environment) resolveBuiltinCall(fc *ast.FunctionCall, args *Tuple) *Tuple {
+	name, ok := fc.Name.(*ast.Identifier)
+	if !ok || e.Scopes.Uses[name] != nil || len(fc.Args.Pairs) == 0 {
+		return nil
+	}
+	switch name.Token.Literal {
+	case "assert":
+		// The arguments are only returned if the first one is truthy
+		results := &Tuple{Types: slices.Clone(args.Types), Rest: args.Rest}
+		if len(results.Types) > 0 {
+			results.Types[0] = typeOrUnknown(truthy(results.Types[0]))
+		}
+		return results
+	case "require":
+		return e.requireResults(fc)
+	case "select":
+		return selectResults(fc, args)
+	case "setmetatable":
+		return e.setmetatableResults(args)
+	}
+	return nil
+}
+
+// setmetatableResults returns the table passed to setmetatable, whose
+// metatable is set to the second argument. Tables that this file didn't
+// create are shared with other files, so a new table is returned instead.
+func (e *Environment) setmetatableResults(args *Tuple) *Tuple {
+	tbl, ok := args.At(0).(*Table)
+	if !ok {
+		return NewTuple(args.At(0))
+	}
+	if !e.ownTables[tbl] {
+		if tbl.Name != "" {
+			// The new table is not the class, but has its fields
+			tbl = &Table{Parents: []*Table{tbl}, Metatable: tbl.Metatable}
+		} else {
+			derived := *tbl
+			derived.Fields = slices.Clone(tbl.Fields)
+			tbl = &derived
+		}
+	}
+	switch metatable := args.At(1).(type) {
+	case *Table:
+		tbl.Metatable = metatable
+	case *Nil:
+		tbl.Metatable = nil
+	}
+	return NewTuple(tbl)
+}
+
+// requireResults returns the value of the module loaded by require, or nil
+// if its name is not a string literal.
+func (e *Environment) requireResults(fc *ast.FunctionCall) *Tuple {
+	lit, ok := fc.Args.Pairs[0].Node.(*ast.StringLiteral)
+	if !ok {
+		return nil
+	}
+	name, ok := stringValue(lit)
+	if !ok {
+		return nil
+	}
+	e.Requires[lit] = name
+	if e.Require == nil {
+		return NewTuple(&Unknown{})
+	}
+	module := e.Require(name)
+	if module == nil {
+		e.addError(moduleNotFound, lit, "Module '%s' not found", name)
+		return NewTuple(&Unknown{})
+	}
+	// Assignments in this file must not change the module
+	return NewTuple(copyType(module))
+}
+
+// selectResults returns the results of select('#', ...), which is the number
+// of values, or select(n, ...), which is the values after the n-th.
+func selectResults(fc *ast.FunctionCall, args *Tuple) *Tuple {
+	switch index := fc.Args.Pairs[0].Node.(type) {
+	case *ast.StringLiteral:
+		if value, ok := stringValue(index); ok && value == "#" {
+			return NewTuple(&Number{})
+		}
+	case *ast.NumberLiteral:
+		if index.Token.Type == token.NUMBER {
+			n := 0
+			for _, c := range index.Token.Literal {
+				if c < '0' || c > '9' {
+					n = -1
+					break
+				}
+				n = n*10 + int(c-'0')
+			}
+			if n > 0 {
+				rest := &Tuple{Rest: args.Rest}
+				if n < len(args.Types) {
+					rest.Types = args.Types[n:]
+				}
+				return rest
+			}
+		}
+	}
+	return &Tuple{Rest: &Unknown{}}
+}
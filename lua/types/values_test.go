@@ -0,0 +1,144 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raiguard/luapls/lua/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipleValues(t *testing.T) {
+	src := `---@return number, string
+local function pair() end
+
+---@param ... string
+---@return ...boolean
+local function variadic(...)
+  local first = ...
+  local count = select("#", ...)
+  local second, third = select(2, ...)
+end
+
+local a, b = pair()
+local c, d, e = pair(), 1
+local f, g, h = 1, pair()
+local i = (pair())
+local j, k = variadic("x", "y")
+`
+	env := newTestEnvironment(src)
+	typeAt := func(word string) string {
+		typ := env.Types[identAt(t, env, strings.Index(src, word))]
+		if typ == nil {
+			return ""
+		}
+		return typ.String()
+	}
+	assert.Equal(t, "function() → number, string", typeAt("pair()"))
+	assert.Equal(t, "function(...: string) → ...boolean", typeAt("variadic(...)"))
+
+	assert.Equal(t, "number", typeAt("a, b ="))
+	assert.Equal(t, "string", typeAt("b = pair"))
+	assert.Equal(t, "number", typeAt("c, d"))
+	assert.Equal(t, "number", typeAt("d, e"))
+	assert.Equal(t, "nil", typeAt("e = pair"))
+	assert.Equal(t, "number", typeAt("g, h"))
+	assert.Equal(t, "string", typeAt("h = 1"))
+	assert.Equal(t, "number", typeAt("i = ("))
+	assert.Equal(t, "boolean?", typeAt("j, k"))
+
+	assert.Equal(t, "string?", typeAt("first ="))
+	assert.Equal(t, "number", typeAt("count ="))
+	assert.Equal(t, "string?", typeAt("second, third"))
+	assert.Empty(t, withoutUnused(env.Errors))
+}
+
+func TestCallArguments(t *testing.T) {
+	src := `---@param a number
+---@param b number?
+local function f(a, b) end
+---@param ... number
+local function g(...) end
+---@return string, string
+local function strings() end
+
+f(1)
+f()
+f(1, 2, 3)
+f(strings())
+g(1, 2, "3")
+local x = ...
+local function h() return ... end
+`
+	env := newTestEnvironment(src)
+	errors := []string{}
+	for _, err := range withoutUnused(env.Errors) {
+		errors = append(errors, err.Message+": "+src[err.Range.Start:err.Range.End])
+	}
+	assert.Equal(t, []string{
+		"Too few function parameters, expected 2, got 0: f()",
+		"Unused parameter: 3",
+		"Cannot use 'string' as 'number' in argument.: strings()",
+		"Cannot use 'string' as 'number?' in argument.: strings()",
+		`Cannot use 'string' as 'number' in argument.: "3"`,
+		"Cannot use '...' outside of a variadic function: ...",
+	}, errors)
+}
+
+func TestRequire(t *testing.T) {
+	module := newTestEnvironment(`local M = {}
+function M.greet(name)
+  return "Hello " .. name
+end
+return M
+`)
+	assert.Equal(t, "{greet: function(name: unknown) → string}", module.Module.String())
+	assert.Equal(t, "boolean", newTestEnvironment("local x = 1").Module.String())
+
+	src := `local m = require("greeter")
+local missing = require("missing")
+local dynamic = require(m.greet("x"))
+`
+	file := parser.New(src).ParseFile()
+	env := NewEnvironment(&file)
+	env.Require = func(name string) Type {
+		if name == "greeter" {
+			return module.Module
+		}
+		return nil
+	}
+	env.ResolveTypes()
+	assert.Equal(t, module.Module, env.Types[identAt(t, &env, strings.Index(src, "m ="))])
+	assert.Equal(t, "unknown", env.Types[identAt(t, &env, strings.Index(src, "missing ="))].String())
+	assert.Equal(t, "any", env.Types[identAt(t, &env, strings.Index(src, "dynamic"))].String())
+	names := []string{}
+	for _, name := range env.Requires {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{"greeter", "missing"}, names)
+	require.Len(t, withoutUnused(env.Errors), 1)
+	assert.Equal(t, "Module 'missing' not found", withoutUnused(env.Errors)[0].Message)
+}
+
+func TestSetmetatable(t *testing.T) {
+	module := newTestEnvironment(`return { name = "a" }`)
+	src := `---@class Point
+local Point = {}
+setmetatable(Point, { __index = { x = 1 } })
+local own = setmetatable({}, { __index = { y = 1 } })
+local m = setmetatable(require("a"), { __index = { z = 1 } })
+local s = setmetatable(string, { __index = { w = 1 } })
+print(Point.x, own.y, m.name, m.z, s.w, s.len)
+`
+	file := parser.New(src).ParseFile()
+	env := NewEnvironment(&file)
+	env.Require = func(name string) Type { return module.Module }
+	env.ResolveTypes()
+	assert.Empty(t, withoutUnused(env.Errors))
+
+	// Tables that the file didn't create are not changed
+	assert.Nil(t, module.Module.(*Table).Metatable)
+	assert.Nil(t, env.GlobalTypes["string"].(*Table).Metatable)
+	assert.NotNil(t, env.Types[identAt(t, &env, strings.Index(src, "s ="))].(*Table).Metatable)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,11 +27,8 @@ func main() {
 
 	switch task {
 	case "lex":
-		if len(args) < 3 {
-			fmt.Fprintln(os.Stderr, "Did not provide a filename")
-			os.Exit(1)
-		}
-		lexFile(args[2])
+		dialect, filename := parseFileArgs(task, args[2:])
+		lexFile(filename, dialect)
 	case "lsp":
 		level := int64(0)
 		if len(args) > 2 {
@@ -38,11 +36,8 @@ func main() {
 		}
 		lsp.Run(int(level))
 	case "parse":
-		if len(args) < 3 {
-			fmt.Fprintln(os.Stderr, "Did not provide a filename")
-			os.Exit(1)
-		}
-		parseFile(args[2])
+		dialect, filename := parseFileArgs(task, args[2:])
+		parseFile(filename, dialect)
 	case "make-test":
 		if len(args) < 4 {
 			fmt.Fprintln(os.Stderr, "Not enough arguments: luapls make-test <suite> <label> <input string>")
@@ -52,7 +47,8 @@ func main() {
 	case "repl":
 		repl.Run()
 	case "check":
-		checkFile(args[2])
+		dialect, filename := parseFileArgs(task, args[2:])
+		checkFile(filename, dialect)
 	default:
 		fmt.Fprintf(os.Stderr, "%s: unrecognized subcommand\n", task)
 	}
@@ -60,12 +56,30 @@ func main() {
 	util.Exit(0)
 }
 
-func lexFile(filename string) {
+// parseFileArgs parses the flags shared by the subcommands that operate on a
+// single file, and returns the selected dialect and filename.
+func parseFileArgs(task string, args []string) (token.Dialect, string) {
+	flags := flag.NewFlagSet(task, flag.ExitOnError)
+	dialectName := flags.String("dialect", token.DefaultDialect.String(), "Lua dialect: 5.1, 5.2, 5.3, 5.4 or luajit")
+	flags.Parse(args)
+	dialect, ok := token.ParseDialect(*dialectName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown dialect '%s'\n", *dialectName)
+		os.Exit(1)
+	}
+	if flags.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "Did not provide a filename")
+		os.Exit(1)
+	}
+	return dialect, flags.Arg(0)
+}
+
+func lexFile(filename string, dialect token.Dialect) {
 	src, err := os.ReadFile(filename)
 	if err != nil {
 		panic(err)
 	}
-	l := lexer.New(string(src))
+	l := lexer.NewWithDialect(string(src), dialect)
 	for {
 		tok := l.Next()
 		fmt.Println(tok.String())
@@ -76,7 +90,7 @@ func lexFile(filename string) {
 	fmt.Println(l.GetLineBreaks())
 }
 
-func parseFile(filename string) {
+func parseFile(filename string, dialect token.Dialect) {
 	before := time.Now()
 	src, err := os.ReadFile(filename)
 	if err != nil {
@@ -89,7 +103,7 @@ func parseFile(filename string) {
 	// 	panic(err)
 	// }
 	// fmt.Println(string(bytes))
-	p := parser.New(string(src))
+	p := parser.NewWithDialect(string(src), dialect)
 	file := p.ParseFile()
 	duration := time.Since(before)
 	bytes, err := json.MarshalIndent(struct {
@@ -135,13 +149,13 @@ func readOrMakeSpecs(path string) []testSpec {
 	return specs
 }
 
-func checkFile(path string) {
+func checkFile(path string, dialect token.Dialect) {
 	src, err := os.ReadFile(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	p := parser.New(string(src))
+	p := parser.NewWithDialect(string(src), dialect)
 	file := p.ParseFile()
 	if len(file.Errors) > 0 {
 		fmt.Println("Parsing errors:")